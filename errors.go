@@ -0,0 +1,39 @@
+package midi
+
+import (
+	"fmt"
+)
+
+// ReadError is returned by the live and SMF readers if a message could not be read.
+// It tells where the reading failed and wraps the underlying error.
+type ReadError struct {
+	// Offset is the position (in bytes from the start of the input) of the message that could not be read.
+	Offset int64
+
+	// Track is the number of the track the message belongs to (starting with 0).
+	// It is -1 for live data and for errors outside of a track (e.g. in the SMF header).
+	Track int16
+
+	// AbsTicks is the absolute time in ticks of the message since the start of the track.
+	// It is always 0 for live data.
+	AbsTicks uint64
+
+	// Status is the status byte of the message (0 if it is not known)
+	Status byte
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the error message including the position
+func (e *ReadError) Error() string {
+	if e.Track < 0 {
+		return fmt.Sprintf("read error at offset %v (status % X): %v", e.Offset, e.Status, e.Err)
+	}
+	return fmt.Sprintf("read error at offset %v (track %v, tick %v, status % X): %v", e.Offset, e.Track, e.AbsTicks, e.Status, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
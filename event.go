@@ -0,0 +1,151 @@
+package midi
+
+import (
+	"github.com/gomidi/midi/internal/vlq"
+)
+
+// EventKind is the kind of MIDI message an Event holds
+type EventKind uint8
+
+const (
+	// KindUnknown is the kind of an empty Event
+	KindUnknown EventKind = iota
+
+	// KindNoteOff is a note-off message (type 8 or note-on with velocity 0). Data1: key, Data2: velocity
+	KindNoteOff
+
+	// KindNoteOn is a note-on message with velocity > 0. Data1: key, Data2: velocity
+	KindNoteOn
+
+	// KindPolyAftertouch is a polyphonic aftertouch message. Data1: key, Data2: pressure
+	KindPolyAftertouch
+
+	// KindControlChange is a control change message. Data1: controller, Data2: value
+	KindControlChange
+
+	// KindProgramChange is a program change message. Data1: program
+	KindProgramChange
+
+	// KindAftertouch is a (channel) aftertouch message. Data1: pressure
+	KindAftertouch
+
+	// KindPitchbend is a pitch bend message. Data1: LSB, Data2: MSB (see Event.Pitchbend)
+	KindPitchbend
+
+	// KindSysEx is a system exclusive message. Status is 0xF0 or 0xF7 and Payload holds the bytes following the status
+	// (including a terminating 0xF7, if there is one)
+	KindSysEx
+
+	// KindSysCommon is a system common message. Status is the status byte (0xF1 - 0xF6) and Data1 and Data2 hold the data bytes
+	KindSysCommon
+
+	// KindRealtime is a system realtime message. Status is the status byte (0xF8 - 0xFF)
+	KindRealtime
+
+	// KindMeta is a SMF meta message. MetaType is the type of the meta message and Payload holds its data
+	KindMeta
+)
+
+// Event is a MIDI message that can be reused for reading, to avoid allocations.
+// It is an alternative to the typed messages, where reading performance matters.
+// Readers that support it implement the EventReader interface.
+type Event struct {
+	// Kind is the kind of the message
+	Kind EventKind
+
+	// Channel is the MIDI channel (0-15) for channel messages
+	Channel uint8
+
+	// Status is the status byte as it has been read.
+	// For channel messages AppendRaw takes the status from Kind and Channel instead.
+	Status byte
+
+	// Data1 and Data2 are the data bytes of channel and system common messages
+	Data1, Data2 uint8
+
+	// MetaType is the type of a meta message
+	MetaType byte
+
+	// Payload is the data of sysex and meta messages. Its underlying array is reused when reading into the Event.
+	Payload []byte
+}
+
+// EventReader reads MIDI messages into reusable events
+type EventReader interface {
+	// ReadEvent reads the next MIDI message into ev.
+	// ev.Payload is reused, so the bytes must be copied, if they are needed after the next read.
+	ReadEvent(ev *Event) error
+}
+
+// Reset clears the event but keeps the underlying array of the payload
+func (e *Event) Reset() {
+	*e = Event{Payload: e.Payload[:0]}
+}
+
+// Key returns the key of note and polyphonic aftertouch messages
+func (e *Event) Key() uint8 {
+	return e.Data1
+}
+
+// Velocity returns the velocity of note messages
+func (e *Event) Velocity() uint8 {
+	return e.Data2
+}
+
+// Pitchbend returns the relative value of a pitch bend message (-8192 to 8191)
+func (e *Event) Pitchbend() int16 {
+	return int16(uint16(e.Data2&0x7F)<<7|uint16(e.Data1&0x7F)) - 0x2000
+}
+
+// IsChannelMessage returns true if the event is a channel message
+func (e *Event) IsChannelMessage() bool {
+	return e.Kind >= KindNoteOff && e.Kind <= KindPitchbend
+}
+
+var kindStatus = [...]byte{
+	KindNoteOff:        0x80,
+	KindNoteOn:         0x90,
+	KindPolyAftertouch: 0xA0,
+	KindControlChange:  0xB0,
+	KindProgramChange:  0xC0,
+	KindAftertouch:     0xD0,
+	KindPitchbend:      0xE0,
+}
+
+// AppendRaw appends the raw bytes of the message to dst and returns the extended slice.
+// The bytes are the same as Raw() returns for the corresponding typed message.
+// Especially a note-off with velocity 0 is written as note-on with velocity 0.
+// It does not allocate, if dst has enough capacity.
+func (e *Event) AppendRaw(dst []byte) []byte {
+	switch e.Kind {
+	case KindNoteOff:
+		if e.Data2 == 0 {
+			return append(dst, 0x90|e.Channel&0x0F, e.Data1&0x7F, 0)
+		}
+		return append(dst, 0x80|e.Channel&0x0F, e.Data1&0x7F, e.Data2&0x7F)
+	case KindProgramChange, KindAftertouch:
+		return append(dst, kindStatus[e.Kind]|e.Channel&0x0F, e.Data1&0x7F)
+	case KindNoteOn, KindPolyAftertouch, KindControlChange, KindPitchbend:
+		return append(dst, kindStatus[e.Kind]|e.Channel&0x0F, e.Data1&0x7F, e.Data2&0x7F)
+	case KindSysEx:
+		dst = append(dst, e.Status)
+		return append(dst, e.Payload...)
+	case KindSysCommon:
+		switch e.Status {
+		case 0xF2:
+			return append(dst, e.Status, e.Data1, e.Data2)
+		case 0xF1, 0xF3:
+			return append(dst, e.Status, e.Data1)
+		default:
+			return append(dst, e.Status)
+		}
+	case KindRealtime:
+		return append(dst, e.Status)
+	case KindMeta:
+		dst = append(dst, 0xFF, e.MetaType)
+		dst = vlq.Append(dst, uint32(len(e.Payload)))
+		return append(dst, e.Payload...)
+	default:
+		return dst
+	}
+}
@@ -1,6 +1,8 @@
 package midilib
 
 import (
+	"bytes"
+	"errors"
 	"io"
 
 	"github.com/gomidi/midi"
@@ -13,6 +15,12 @@ in the github.com/afandian/go-midi package of Joe Wass.
 See the file midi_functions.go for the original functions.
 */
 
+// allocStep is the size up to which ReadVarLengthData allocates the complete data at once
+const allocStep = 4096
+
+// ErrVarLengthOverflow is returned, if a variable length value is longer than 4 bytes
+var ErrVarLengthOverflow = errors.New("variable length value exceeds 4 bytes")
+
 // ReadUint16 reads a 2-byte 16 bit integer from a Reader.
 // It returns the 16-bit value and an error.
 // This is a slightly modified variant of the parseUint16 function
@@ -76,31 +84,32 @@ func ReadUint32(rd io.Reader) (uint32, error) {
 // from Joe Wass. See the file midi_functions.go for the original.
 func ReadVarLength(reader io.Reader) (uint32, error) {
 
-	// Single byte buffer to read byte by byte.
-	var buffer []byte = make([]uint8, 1)
-
-	// The number of bytes returned.
-	// Should always be 1 unless we reach the EOF
-	var num int = 1
-
 	// Result value
 	var result uint32 = 0x00
 
 	// RTFM.
-	var first = true
-	for (first || (buffer[0]&0x80 == 0x80)) && (num > 0) {
-		result = result << 7
+	for i := 0; ; i++ {
+		// the largest allowed value 0FFFFFFF fits into 4 bytes
+		if i == 4 {
+			return 0, ErrVarLengthOverflow
+		}
 
-		num, _ = reader.Read(buffer)
-		result |= (uint32(buffer[0]) & 0x7f)
-		first = false
-	}
+		b, err := ReadByte(reader)
 
-	if num == 0 && !first {
-		return result, midi.ErrUnexpectedEOF
-	}
+		if err == io.EOF {
+			return result, midi.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return result, err
+		}
 
-	return result, nil
+		result = result<<7 | (uint32(b) & 0x7f)
+
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
 }
 
 // ReadVarLengthData reads data that is prefixed by a varLength that tells the length of the data
@@ -114,12 +123,30 @@ func ReadVarLengthData(reader io.Reader) ([]byte, error) {
 		return []byte{}, err
 	}
 
+	// for larger data, the buffer grows with the data that is actually read,
+	// so that a wrong length can't force a large allocation
+	if length > allocStep {
+		var bf bytes.Buffer
+		var num int64
+		num, err = io.CopyN(&bf, reader, int64(length))
+
+		if num != int64(length) && (err == nil || err == io.EOF) {
+			err = midi.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return []byte{}, err
+		}
+
+		return bf.Bytes(), nil
+	}
+
 	var buffer []byte = make([]byte, length)
 
-	num, err := reader.Read(buffer)
+	_, err = io.ReadFull(reader, buffer)
 
 	// If we couldn't read the entire expected-length buffer, that's a problem.
-	if num != int(length) {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
 		return []byte{}, midi.ErrUnexpectedEOF
 	}
 
@@ -0,0 +1,91 @@
+package midilib
+
+import (
+	"io"
+
+	"github.com/gomidi/midi"
+)
+
+/*
+functions in this file are _not_ derived from the work of Joe Wass.
+*/
+
+var channelKinds = [...]midi.EventKind{
+	0x8: midi.KindNoteOff,
+	0x9: midi.KindNoteOn,
+	0xA: midi.KindPolyAftertouch,
+	0xB: midi.KindControlChange,
+	0xC: midi.KindProgramChange,
+	0xD: midi.KindAftertouch,
+	0xE: midi.KindPitchbend,
+}
+
+// ReadChannelEvent reads a channel message with the given status (0x80 - 0xEF) and first data byte into ev.
+// The second data byte is read from rd, if the message has one.
+// Note-on messages with velocity 0 are read as note-off messages.
+func ReadChannelEvent(ev *midi.Event, status, arg1 byte, rd io.Reader) (err error) {
+	typ, channel := ParseStatus(status)
+
+	ev.Kind = channelKinds[typ]
+	ev.Channel = channel
+	ev.Status = status
+	ev.Data1 = ParseUint7(arg1)
+	ev.Data2 = 0
+
+	if ev.Kind == midi.KindProgramChange || ev.Kind == midi.KindAftertouch {
+		return nil
+	}
+
+	var arg2 byte
+	arg2, err = ReadByte(rd)
+
+	if err != nil {
+		return
+	}
+
+	ev.Data2 = ParseUint7(arg2)
+
+	if ev.Kind == midi.KindNoteOn && ev.Data2 == 0 {
+		ev.Kind = midi.KindNoteOff
+	}
+
+	return nil
+}
+
+// ReadDataInto reads length bytes from rd and appends them to dst.
+// The capacity of dst is reused. If it is too small, dst grows with the data
+// that is actually read, so that a wrong length can't force a large allocation.
+func ReadDataInto(dst []byte, rd io.Reader, length uint32) ([]byte, error) {
+	for remaining := int(length); remaining > 0; {
+		n := remaining
+
+		if free := cap(dst) - len(dst); free < n {
+			if n > allocStep {
+				n = allocStep
+			}
+
+			if free < n {
+				nb := make([]byte, len(dst), 2*cap(dst)+n)
+				copy(nb, dst)
+				dst = nb
+			}
+		}
+
+		start := len(dst)
+		dst = dst[:start+n]
+
+		_, err := io.ReadFull(rd, dst[start:])
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return dst[:start], midi.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return dst[:start], err
+		}
+
+		remaining -= n
+	}
+
+	return dst, nil
+}
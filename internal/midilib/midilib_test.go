@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/internal/vlq"
 )
 
@@ -160,3 +161,24 @@ func TestLibBits(t *testing.T) {
 	}
 
 }
+
+func TestReadVarLengthDataTruncated(t *testing.T) {
+	// claims 256MB, but has only 3 bytes
+	bf := bytes.NewReader([]byte{0xFF, 0xFF, 0xFF, 0x7F, 0x41, 0x42, 0x43})
+
+	_, err := ReadVarLengthData(bf)
+
+	if err != midi.ErrUnexpectedEOF {
+		t.Errorf("expected midi.ErrUnexpectedEOF, got: %v", err)
+	}
+}
+
+func TestReadVarLengthOverflow(t *testing.T) {
+	bf := bytes.NewReader([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x7F})
+
+	_, err := ReadVarLength(bf)
+
+	if err != ErrVarLengthOverflow {
+		t.Errorf("expected ErrVarLengthOverflow, got: %v", err)
+	}
+}
@@ -77,8 +77,13 @@ func ReadNBytes(n int, rd io.Reader) ([]byte, error) {
 	return b, err
 }
 
-// ReadByte reads a byte from the reader
+// ReadByte reads a byte from the reader.
+// If the reader is an io.ByteReader, its ReadByte method is used to avoid allocations.
 func ReadByte(rd io.Reader) (byte, error) {
+	if br, is := rd.(io.ByteReader); is {
+		return br.ReadByte()
+	}
+
 	b, err := ReadNBytes(1, rd)
 
 	if err != nil {
@@ -87,3 +92,45 @@ func ReadByte(rd io.Reader) (byte, error) {
 
 	return b[0], nil
 }
+
+// Counter is an io.Reader that counts the bytes that have been read from
+// the underlying Reader.
+// It is also an io.ByteReader.
+type Counter struct {
+	Reader io.Reader
+	N      int64
+	buf    [1]byte
+}
+
+// ReadByte reads a single byte from the underlying Reader and counts it.
+// If the underlying Reader is an io.ByteReader, its ReadByte method is used.
+func (c *Counter) ReadByte() (b byte, err error) {
+	if br, is := c.Reader.(io.ByteReader); is {
+		b, err = br.ReadByte()
+		if err == nil {
+			c.N++
+		}
+		return
+	}
+
+	var n int
+	n, err = c.Reader.Read(c.buf[:])
+	c.N += int64(n)
+
+	if n == 1 {
+		return c.buf[0], nil
+	}
+
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+
+	return 0, err
+}
+
+// Read reads from the underlying Reader and counts the bytes that have been read
+func (c *Counter) Read(p []byte) (n int, err error) {
+	n, err = c.Reader.Read(p)
+	c.N += int64(n)
+	return
+}
@@ -57,3 +57,20 @@ func Decode(source []byte) (num uint32) {
 
 	return
 }
+
+// Append appends the variable length quantity of the given value to dst
+// and returns the extended slice. It does not allocate, if dst has enough capacity.
+func Append(dst []byte, n uint32) []byte {
+	var buf [5]byte
+	i := len(buf) - 1
+	buf[i] = byte(n % vlqContinue)
+	n = n / vlqContinue
+
+	for n > 0 {
+		i--
+		buf[i] = byte(n%vlqContinue) | vlqContinue
+		n = n / vlqContinue
+	}
+
+	return append(dst, buf[i:]...)
+}
@@ -44,3 +44,13 @@ func TestDecode(t *testing.T) {
 	}
 
 }
+
+func TestAppend(t *testing.T) {
+	for _, test := range tests {
+		var b = Append([]byte{0xAA}, test.num)
+
+		if got, want := fmt.Sprintf("%X", b), fmt.Sprintf("AA%X", test.bytes); got != want {
+			t.Errorf("Append([]byte{0xAA}, %v) = %#v; want %#v", test.num, got, want)
+		}
+	}
+}
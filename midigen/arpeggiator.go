@@ -0,0 +1,437 @@
+package midigen
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+// ArpMode is the order in which the arpeggiator plays the held notes
+type ArpMode int
+
+const (
+	// Up plays the notes from the lowest to the highest
+	Up ArpMode = iota
+
+	// Down plays the notes from the highest to the lowest
+	Down
+
+	// UpDown plays the notes up and then down, without repeating the highest and the lowest note
+	UpDown
+
+	// Random plays the notes in random order
+	Random
+
+	// AsPlayed plays the notes in the order they were pressed
+	AsPlayed
+)
+
+// ArpOption is an option for the Arpeggiator
+type ArpOption func(*Arpeggiator)
+
+// Mode sets the order of the notes (default: Up)
+func Mode(m ArpMode) ArpOption {
+	return func(a *Arpeggiator) {
+		a.mode = m
+	}
+}
+
+// Octaves sets the number of octaves over which the held notes are repeated (default: 1)
+func Octaves(n uint8) ArpOption {
+	return func(a *Arpeggiator) {
+		if n < 1 {
+			n = 1
+		}
+		a.octaves = n
+	}
+}
+
+// Gate sets the length of the notes in percent of the distance between two notes (1-100, default: 50)
+func Gate(percent uint8) ArpOption {
+	return func(a *Arpeggiator) {
+		switch {
+		case percent < 1:
+			percent = 1
+		case percent > 100:
+			percent = 100
+		}
+		a.gate = percent
+	}
+}
+
+// Rate sets the note value of the distance between two notes (default: 16 for sixteenth notes)
+func Rate(noteValue uint8) ArpOption {
+	return func(a *Arpeggiator) {
+		if noteValue < 1 {
+			noteValue = 1
+		}
+		a.noteValue = noteValue
+	}
+}
+
+// Tempo sets the tempo in beats (quarter notes) per minute (default: 120)
+func Tempo(bpm float64) ArpOption {
+	return func(a *Arpeggiator) {
+		if bpm > 0 {
+			a.bpm = bpm
+		}
+	}
+}
+
+// SyncToClock syncs the arpeggiator to the incoming realtime.TimingClock messages (24 per quarter note)
+// instead of the tempo. realtime.Start restarts the pattern and realtime.Stop ends the sounding note.
+func SyncToClock() ArpOption {
+	return func(a *Arpeggiator) {
+		a.syncClock = true
+	}
+}
+
+// Seed sets the seed of the random order (see Random)
+func Seed(seed int64) ArpOption {
+	return func(a *Arpeggiator) {
+		a.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+type heldNote struct {
+	channel, key, velocity uint8
+}
+
+// Arpeggiator plays the held notes one after another.
+// Each generated note has the channel and velocity of the note it is generated from.
+type Arpeggiator struct {
+	mx        sync.Mutex
+	output    midi.Writer
+	scheduler Scheduler
+
+	mode      ArpMode
+	octaves   uint8
+	gate      uint8
+	noteValue uint8
+	bpm       float64
+	syncClock bool
+	rand      *rand.Rand
+
+	// held are the pressed notes in the order they were pressed
+	held     []heldNote
+	step     int
+	sounding *heldNote
+	err      error
+
+	// internal clock
+	running    bool
+	startedAt  time.Duration
+	steps      int64
+	stepID     int
+	cancelStep func()
+	cancelOff  func()
+
+	// clock sync
+	clockPos uint64
+	offPos   uint64
+}
+
+// NewArpeggiator returns an Arpeggiator that writes the generated notes to wr.
+// The timing is done by the given scheduler (nil means RealTime).
+func NewArpeggiator(wr midi.Writer, sched Scheduler, opts ...ArpOption) *Arpeggiator {
+	if sched == nil {
+		sched = RealTime
+	}
+
+	a := &Arpeggiator{
+		output:    wr,
+		scheduler: sched,
+		octaves:   1,
+		gate:      50,
+		noteValue: 16,
+		bpm:       120,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.rand == nil {
+		a.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return a
+}
+
+// Write consumes note on and note off messages and passes all other messages to the output.
+// An error of writing a scheduled note is returned by the next call of Write (after the message has been handled).
+func (a *Arpeggiator) Write(msg midi.Message) error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	err := a.write(msg)
+
+	if a.err != nil {
+		err, a.err = a.err, nil
+	}
+
+	return err
+}
+
+func (a *Arpeggiator) write(msg midi.Message) error {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return a.release(v.Channel(), v.Key())
+		}
+		return a.press(heldNote{channel: v.Channel(), key: v.Key(), velocity: v.Velocity()})
+	case channel.NoteOff:
+		return a.release(v.Channel(), v.Key())
+	case channel.NoteOffVelocity:
+		return a.release(v.Channel(), v.Key())
+	}
+
+	if err := a.output.Write(msg); err != nil {
+		return err
+	}
+
+	if !a.syncClock {
+		return nil
+	}
+
+	switch msg {
+	case realtime.TimingClock:
+		return a.clock()
+	case realtime.Start:
+		a.clockPos = 0
+		a.step = 0
+	case realtime.Stop:
+		return a.noteOff()
+	}
+
+	return nil
+}
+
+// Stop stops the internal clock and ends the sounding note. The held notes are forgotten.
+func (a *Arpeggiator) Stop() error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	a.held = nil
+	a.step = 0
+	a.stopClock()
+	return a.noteOff()
+}
+
+func (a *Arpeggiator) press(n heldNote) error {
+	for _, h := range a.held {
+		if h.channel == n.channel && h.key == n.key {
+			return nil
+		}
+	}
+
+	a.held = append(a.held, n)
+
+	if a.syncClock || a.running {
+		return nil
+	}
+
+	a.running = true
+	a.startedAt = a.scheduler.Now()
+	a.steps = 0
+	return a.tick()
+}
+
+func (a *Arpeggiator) release(ch, key uint8) error {
+	for i, h := range a.held {
+		if h.channel == ch && h.key == key {
+			a.held = append(a.held[:i], a.held[i+1:]...)
+			break
+		}
+	}
+
+	if len(a.held) == 0 {
+		a.step = 0
+		a.stopClock()
+	}
+
+	return nil
+}
+
+func (a *Arpeggiator) stopClock() {
+	a.running = false
+
+	if a.cancelStep != nil {
+		a.cancelStep()
+		a.cancelStep = nil
+	}
+}
+
+// pattern returns the notes of one run through the pattern
+func (a *Arpeggiator) pattern() []heldNote {
+	notes := make([]heldNote, len(a.held))
+	copy(notes, a.held)
+
+	if a.mode != AsPlayed {
+		sort.SliceStable(notes, func(i, j int) bool {
+			return notes[i].key < notes[j].key
+		})
+	}
+
+	var pat []heldNote
+
+	for o := 0; o < int(a.octaves); o++ {
+		for _, n := range notes {
+			if k := int(n.key) + 12*o; k < 128 {
+				n.key = uint8(k)
+				pat = append(pat, n)
+			}
+		}
+	}
+
+	switch a.mode {
+	case Down:
+		for i, j := 0, len(pat)-1; i < j; i, j = i+1, j-1 {
+			pat[i], pat[j] = pat[j], pat[i]
+		}
+	case UpDown:
+		for i := len(pat) - 2; i > 0; i-- {
+			pat = append(pat, pat[i])
+		}
+	}
+
+	return pat
+}
+
+// next returns the note of the next step
+func (a *Arpeggiator) next() (n heldNote, ok bool) {
+	pat := a.pattern()
+
+	if len(pat) == 0 {
+		return n, false
+	}
+
+	if a.mode == Random {
+		return pat[a.rand.Intn(len(pat))], true
+	}
+
+	n = pat[a.step%len(pat)]
+	a.step++
+	return n, true
+}
+
+func (a *Arpeggiator) play(n heldNote) error {
+	if err := a.noteOff(); err != nil {
+		return err
+	}
+
+	a.sounding = &n
+	return a.output.Write(channel.Channel(n.channel).NoteOn(n.key, n.velocity))
+}
+
+func (a *Arpeggiator) noteOff() error {
+	if a.cancelOff != nil {
+		a.cancelOff()
+		a.cancelOff = nil
+	}
+
+	if a.sounding == nil {
+		return nil
+	}
+
+	n := a.sounding
+	a.sounding = nil
+	return a.output.Write(channel.Channel(n.channel).NoteOff(n.key))
+}
+
+// stepDuration returns the duration between two notes of the internal clock
+func (a *Arpeggiator) stepDuration() time.Duration {
+	return time.Duration(float64(time.Minute) / a.bpm * 4 / float64(a.noteValue))
+}
+
+// tick plays the next step of the internal clock and schedules the following one
+func (a *Arpeggiator) tick() error {
+	n, ok := a.next()
+
+	if !ok {
+		a.running = false
+		return nil
+	}
+
+	// the next step is scheduled, even if the note could not be written
+	err := a.play(n)
+	d := a.stepDuration()
+	sounding := a.sounding
+
+	// the times are based on the start, so that the latencies of the scheduler don't add up
+	at := a.startedAt + time.Duration(a.steps)*d - a.scheduler.Now()
+	a.steps++
+
+	a.cancelOff = a.scheduler.AfterFunc(at+d*time.Duration(a.gate)/100, func() {
+		a.mx.Lock()
+		defer a.mx.Unlock()
+
+		if a.sounding == sounding {
+			a.setError(a.noteOff())
+		}
+	})
+
+	a.stepID++
+	id := a.stepID
+
+	a.cancelStep = a.scheduler.AfterFunc(at+d, func() {
+		a.mx.Lock()
+		defer a.mx.Unlock()
+
+		if a.running && a.stepID == id {
+			a.setError(a.tick())
+		}
+	})
+
+	return err
+}
+
+func (a *Arpeggiator) setError(err error) {
+	if err != nil && a.err == nil {
+		a.err = err
+	}
+}
+
+// clock handles a timing clock message, if the arpeggiator is synced to the clock
+func (a *Arpeggiator) clock() error {
+	pos := a.clockPos
+	a.clockPos++
+
+	if a.sounding != nil && pos == a.offPos {
+		if err := a.noteOff(); err != nil {
+			return err
+		}
+	}
+
+	// 24 clocks per quarter note
+	perStep := uint64(96 / int(a.noteValue))
+
+	if perStep == 0 {
+		perStep = 1
+	}
+
+	if pos%perStep != 0 {
+		return nil
+	}
+
+	n, ok := a.next()
+
+	if !ok {
+		return nil
+	}
+
+	gate := uint64(math.Round(float64(perStep) * float64(a.gate) / 100))
+
+	if gate == 0 {
+		gate = 1
+	}
+
+	a.offPos = pos + gate
+	return a.play(n)
+}
@@ -0,0 +1,210 @@
+package midigen
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/meta/key"
+)
+
+// ChordOption is an option for the chord generator
+type ChordOption func(*Chords)
+
+// Degrees sets the voicing of the chords as steps within the scale, starting from the pressed key
+// (default: 0, 2, 4 for a triad). E.g. Degrees(0, 2, 4, 6) plays seventh chords and
+// Degrees(0, 4, 9) plays open triads.
+func Degrees(steps ...int) ChordOption {
+	return func(c *Chords) {
+		c.degrees = steps
+	}
+}
+
+// Strum delays each note of a chord by the given duration after the previous note
+func Strum(d time.Duration) ChordOption {
+	return func(c *Chords) {
+		c.strum = d
+	}
+}
+
+type chord struct {
+	channel, velocity uint8
+	keys              []uint8
+
+	// sent is the number of keys that have been started
+	sent    int
+	cancels []func()
+}
+
+// Chords plays a chord for each pressed key. The chord is built from the scale of a key signature
+// (the natural major or minor scale). A key that is not part of the scale gets the chord of the next lower
+// key of the scale, moved by the difference.
+// If chords share a key, it is ended when the last of them is released.
+type Chords struct {
+	mx        sync.Mutex
+	output    midi.Writer
+	scheduler Scheduler
+
+	key     meta.Key
+	degrees []int
+	strum   time.Duration
+
+	// playing are the chords by the channel and the pressed key
+	playing map[[2]uint8]*chord
+
+	// sounding counts the chords that sound a key, by the channel and the key
+	sounding map[[2]uint8]int
+	err      error
+}
+
+// NewChords returns a chord generator for the scale of the given key signature that writes the chords to wr.
+// The timing of strummed chords is done by the given scheduler (nil means RealTime).
+func NewChords(wr midi.Writer, sched Scheduler, k meta.Key, opts ...ChordOption) *Chords {
+	if sched == nil {
+		sched = RealTime
+	}
+
+	c := &Chords{
+		output:    wr,
+		scheduler: sched,
+		key:       k,
+		degrees:   []int{0, 2, 4},
+		playing:   map[[2]uint8]*chord{},
+		sounding:  map[[2]uint8]int{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// SetKey changes the key signature for the following chords
+func (c *Chords) SetKey(k meta.Key) {
+	c.mx.Lock()
+	c.key = k
+	c.mx.Unlock()
+}
+
+// Write consumes note on and note off messages and passes all other messages to the output.
+// An error of writing a strummed note is returned by the next call of Write (after the message has been handled).
+func (c *Chords) Write(msg midi.Message) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	err := c.write(msg)
+
+	if c.err != nil {
+		err, c.err = c.err, nil
+	}
+
+	return err
+}
+
+func (c *Chords) write(msg midi.Message) error {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return c.release(v.Channel(), v.Key())
+		}
+		return c.press(v.Channel(), v.Key(), v.Velocity())
+	case channel.NoteOff:
+		return c.release(v.Channel(), v.Key())
+	case channel.NoteOffVelocity:
+		return c.release(v.Channel(), v.Key())
+	}
+
+	return c.output.Write(msg)
+}
+
+// keys returns the keys of the chord for the given key
+func (c *Chords) keys(k uint8) []uint8 {
+	var keys []uint8
+	has := map[int]bool{}
+
+	for _, step := range c.degrees {
+		n := key.Diatonic(c.key, int(k), step)
+
+		if n < 0 || n > 127 || has[n] {
+			continue
+		}
+
+		has[n] = true
+		keys = append(keys, uint8(n))
+	}
+
+	return keys
+}
+
+func (c *Chords) press(ch, k, velocity uint8) error {
+	if err := c.release(ch, k); err != nil {
+		return err
+	}
+
+	cd := &chord{channel: ch, velocity: velocity, keys: c.keys(k)}
+	c.playing[[2]uint8{ch, k}] = cd
+
+	for i := range cd.keys {
+		if i == 0 || c.strum <= 0 {
+			if err := c.noteOn(cd); err != nil {
+				return err
+			}
+			continue
+		}
+
+		cd.cancels = append(cd.cancels, c.scheduler.AfterFunc(c.strum*time.Duration(i), func() {
+			c.mx.Lock()
+			defer c.mx.Unlock()
+
+			if c.playing[[2]uint8{ch, k}] == cd && cd.sent < len(cd.keys) {
+				if err := c.noteOn(cd); err != nil && c.err == nil {
+					c.err = err
+				}
+			}
+		}))
+	}
+
+	return nil
+}
+
+// noteOn starts the next key of the chord
+func (c *Chords) noteOn(cd *chord) error {
+	k := cd.keys[cd.sent]
+	cd.sent++
+	c.sounding[[2]uint8{cd.channel, k}]++
+	return c.output.Write(channel.Channel(cd.channel).NoteOn(k, cd.velocity))
+}
+
+func (c *Chords) release(ch, k uint8) error {
+	cd, has := c.playing[[2]uint8{ch, k}]
+
+	if !has {
+		return nil
+	}
+
+	delete(c.playing, [2]uint8{ch, k})
+
+	for _, cancel := range cd.cancels {
+		cancel()
+	}
+
+	for _, n := range cd.keys[:cd.sent] {
+		sk := [2]uint8{ch, n}
+		c.sounding[sk]--
+
+		if c.sounding[sk] > 0 {
+			continue
+		}
+
+		delete(c.sounding, sk)
+
+		if err := c.output.Write(channel.Channel(ch).NoteOff(n)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
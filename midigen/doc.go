@@ -0,0 +1,38 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midigen provides processors that generate notes from live input: an arpeggiator and a chord generator.
+
+Both are midi.Writers that consume the note messages written to them and write the generated notes
+to another midi.Writer. All other messages are passed through. So they sit between a midi.Reader and
+a midi.Writer (see midiroute.Pipe).
+
+The timing is done by a Scheduler. The default is RealTime, tests can inject a virtual implementation.
+
+Usage
+
+	import (
+		"github.com/gomidi/midi/midigen"
+		"github.com/gomidi/midi/midimessage/meta/key"
+		"github.com/gomidi/midi/midireader"
+		"github.com/gomidi/midi/midiroute"
+		"github.com/gomidi/midi/midiwriter"
+	)
+
+	// a triad in A minor for each key, arpeggiated up and down over two octaves
+	arp := midigen.NewArpeggiator(
+		midiwriter.New(out),
+		midigen.RealTime,
+		midigen.Mode(midigen.UpDown),
+		midigen.Octaves(2),
+		midigen.Tempo(96),
+	)
+
+	chords := midigen.NewChords(arp, midigen.RealTime, key.AMin())
+
+	err := midiroute.Pipe(midireader.New(keyboard, nil), chords)
+
+*/
+package midigen
@@ -0,0 +1,343 @@
+package midigen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta/key"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+type pendingCall struct {
+	at        time.Duration
+	f         func()
+	cancelled bool
+}
+
+// virtualScheduler calls the functions when the time is advanced, delayed by the latency
+type virtualScheduler struct {
+	now     time.Duration
+	latency time.Duration
+	pending []*pendingCall
+}
+
+func (v *virtualScheduler) Now() time.Duration {
+	return v.now
+}
+
+func (v *virtualScheduler) AfterFunc(d time.Duration, f func()) func() {
+	c := &pendingCall{at: v.now + d + v.latency, f: f}
+	v.pending = append(v.pending, c)
+	return func() {
+		c.cancelled = true
+	}
+}
+
+// advance calls the pending functions up to the given time in order
+func (v *virtualScheduler) advance(d time.Duration) {
+	end := v.now + d
+
+	for {
+		idx := -1
+
+		for i, c := range v.pending {
+			if !c.cancelled && c.at <= end && (idx < 0 || c.at < v.pending[idx].at) {
+				idx = i
+			}
+		}
+
+		if idx < 0 {
+			break
+		}
+
+		c := v.pending[idx]
+		v.pending = append(v.pending[:idx], v.pending[idx+1:]...)
+		v.now = c.at
+		c.f()
+	}
+
+	v.now = end
+}
+
+// recorder records the written messages with the time of the scheduler
+type recorder struct {
+	sched *virtualScheduler
+	lines []string
+}
+
+func (r *recorder) Write(msg midi.Message) error {
+	r.lines = append(r.lines, fmt.Sprintf("%v %s", r.sched.now, msg))
+	return nil
+}
+
+func (r *recorder) String() string {
+	return strings.Join(r.lines, "\n")
+}
+
+func TestArpeggiatorTempo(t *testing.T) {
+	sched := &virtualScheduler{}
+	rec := &recorder{sched: sched}
+	ch := channel.Channel2
+
+	// sixteenth notes at 120 BPM: 125ms
+	a := NewArpeggiator(rec, sched)
+
+	a.Write(ch.NoteOn(64, 90))
+	a.Write(ch.NoteOn(60, 100))
+	a.Write(ch.ControlChange(7, 80))
+	sched.advance(300 * time.Millisecond)
+	a.Write(ch.NoteOff(60))
+	a.Write(ch.NoteOff(64))
+	sched.advance(time.Second)
+
+	expected := `0s channel.NoteOn channel 2 key 64 velocity 90
+0s channel.ControlChange channel 2 controller 7 ("Volume (MSB)") value 80
+62.5ms channel.NoteOff channel 2 key 64
+125ms channel.NoteOn channel 2 key 64 velocity 90
+187.5ms channel.NoteOff channel 2 key 64
+250ms channel.NoteOn channel 2 key 60 velocity 100
+312.5ms channel.NoteOff channel 2 key 60`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestArpeggiatorLatency(t *testing.T) {
+	sched := &virtualScheduler{latency: 5 * time.Millisecond}
+	rec := &recorder{sched: sched}
+	ch := channel.Channel0
+
+	// the latency does not add up
+	a := NewArpeggiator(rec, sched)
+
+	a.Write(ch.NoteOn(60, 100))
+	sched.advance(400 * time.Millisecond)
+	a.Write(ch.NoteOff(60))
+
+	expected := `0s channel.NoteOn channel 0 key 60 velocity 100
+67.5ms channel.NoteOff channel 0 key 60
+130ms channel.NoteOn channel 0 key 60 velocity 100
+192.5ms channel.NoteOff channel 0 key 60
+255ms channel.NoteOn channel 0 key 60 velocity 100
+317.5ms channel.NoteOff channel 0 key 60
+380ms channel.NoteOn channel 0 key 60 velocity 100`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestArpeggiatorPattern(t *testing.T) {
+	held := []heldNote{{key: 64}, {key: 60}, {key: 67}}
+
+	tests := []struct {
+		mode     ArpMode
+		octaves  uint8
+		expected string
+	}{
+		{Up, 1, "[60 64 67]"},
+		{Up, 2, "[60 64 67 72 76 79]"},
+		{Down, 2, "[79 76 72 67 64 60]"},
+		{UpDown, 1, "[60 64 67 64]"},
+		{AsPlayed, 2, "[64 60 67 76 72 79]"},
+	}
+
+	for _, test := range tests {
+		a := NewArpeggiator(nil, nil, Mode(test.mode), Octaves(test.octaves))
+		a.held = held
+
+		var keys []uint8
+
+		for _, n := range a.pattern() {
+			keys = append(keys, n.key)
+		}
+
+		if got := fmt.Sprint(keys); got != test.expected {
+			t.Errorf("mode %v octaves %v: got %s wanted %s", test.mode, test.octaves, got, test.expected)
+		}
+	}
+}
+
+func TestArpeggiatorClock(t *testing.T) {
+	sched := &virtualScheduler{}
+	rec := &recorder{sched: sched}
+	ch := channel.Channel0
+
+	// 6 clocks per sixteenth note, gate 50%: 3 clocks
+	a := NewArpeggiator(rec, sched, SyncToClock())
+
+	a.Write(ch.NoteOn(64, 90))
+	a.Write(ch.NoteOn(60, 100))
+
+	for i := 0; i < 7; i++ {
+		a.Write(realtime.TimingClock)
+	}
+
+	a.Write(ch.NoteOff(60))
+	a.Write(ch.NoteOff(64))
+
+	for i := 0; i < 6; i++ {
+		a.Write(realtime.TimingClock)
+	}
+
+	expected := `0s TimingClock
+0s channel.NoteOn channel 0 key 60 velocity 100
+0s TimingClock
+0s TimingClock
+0s TimingClock
+0s channel.NoteOff channel 0 key 60
+0s TimingClock
+0s TimingClock
+0s TimingClock
+0s channel.NoteOn channel 0 key 64 velocity 90
+0s TimingClock
+0s TimingClock
+0s TimingClock
+0s channel.NoteOff channel 0 key 64
+0s TimingClock
+0s TimingClock
+0s TimingClock`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestChords(t *testing.T) {
+	sched := &virtualScheduler{}
+	rec := &recorder{sched: sched}
+	ch := channel.Channel0
+
+	c := NewChords(rec, sched, key.CMaj())
+
+	// C and E minor share E and G
+	c.Write(ch.NoteOn(60, 100))
+	c.Write(ch.NoteOn(64, 80))
+	c.Write(ch.NoteOff(60))
+	c.Write(ch.NoteOn(64, 0))
+
+	// not in the scale
+	c.Write(ch.NoteOn(61, 70))
+	c.Write(ch.NoteOff(61))
+
+	expected := `0s channel.NoteOn channel 0 key 60 velocity 100
+0s channel.NoteOn channel 0 key 64 velocity 100
+0s channel.NoteOn channel 0 key 67 velocity 100
+0s channel.NoteOn channel 0 key 64 velocity 80
+0s channel.NoteOn channel 0 key 67 velocity 80
+0s channel.NoteOn channel 0 key 71 velocity 80
+0s channel.NoteOff channel 0 key 60
+0s channel.NoteOff channel 0 key 64
+0s channel.NoteOff channel 0 key 67
+0s channel.NoteOff channel 0 key 71
+0s channel.NoteOn channel 0 key 61 velocity 70
+0s channel.NoteOn channel 0 key 65 velocity 70
+0s channel.NoteOn channel 0 key 68 velocity 70
+0s channel.NoteOff channel 0 key 61
+0s channel.NoteOff channel 0 key 65
+0s channel.NoteOff channel 0 key 68`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestChordsStrum(t *testing.T) {
+	sched := &virtualScheduler{}
+	rec := &recorder{sched: sched}
+	ch := channel.Channel0
+
+	c := NewChords(rec, sched, key.AMin(), Degrees(0, 2, 4, 6), Strum(10*time.Millisecond))
+
+	c.Write(ch.NoteOn(57, 100))
+	sched.advance(25 * time.Millisecond)
+	c.Write(ch.NoteOff(57))
+	sched.advance(time.Second)
+
+	expected := `0s channel.NoteOn channel 0 key 57 velocity 100
+10ms channel.NoteOn channel 0 key 60 velocity 100
+20ms channel.NoteOn channel 0 key 64 velocity 100
+25ms channel.NoteOff channel 0 key 57
+25ms channel.NoteOff channel 0 key 60
+25ms channel.NoteOff channel 0 key 64`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+// failingRecorder fails to write the message with the given number (starting at 1)
+type failingRecorder struct {
+	recorder
+	fail, n int
+}
+
+func (f *failingRecorder) Write(msg midi.Message) error {
+	f.n++
+	if f.n == f.fail {
+		return fmt.Errorf("can't write %s", msg)
+	}
+	return f.recorder.Write(msg)
+}
+
+func TestArpeggiatorErrorRelease(t *testing.T) {
+	sched := &virtualScheduler{}
+	rec := &failingRecorder{recorder: recorder{sched: sched}, fail: 3}
+	ch := channel.Channel0
+
+	a := NewArpeggiator(rec, sched)
+
+	a.Write(ch.NoteOn(60, 100))
+	sched.advance(130 * time.Millisecond)
+
+	// the note off is handled, although the error of the second note is returned
+	if err := a.Write(ch.NoteOff(60)); err == nil || err.Error() != "can't write channel.NoteOn channel 0 key 60 velocity 100" {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	sched.advance(time.Second)
+
+	if err := a.Write(ch.NoteOn(62, 100)); err != nil {
+		t.Errorf("error: %v", err)
+	}
+
+	expected := `0s channel.NoteOn channel 0 key 60 velocity 100
+62.5ms channel.NoteOff channel 0 key 60
+187.5ms channel.NoteOff channel 0 key 60
+1.13s channel.NoteOn channel 0 key 62 velocity 100`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestChordsErrorRelease(t *testing.T) {
+	sched := &virtualScheduler{}
+	rec := &failingRecorder{recorder: recorder{sched: sched}, fail: 2}
+	ch := channel.Channel0
+
+	c := NewChords(rec, sched, key.AMin(), Strum(10*time.Millisecond))
+
+	c.Write(ch.NoteOn(57, 100))
+	sched.advance(25 * time.Millisecond)
+
+	// the chord is released, although the error of the strummed note is returned
+	if err := c.Write(ch.NoteOff(57)); err == nil || err.Error() != "can't write channel.NoteOn channel 0 key 60 velocity 100" {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	expected := `0s channel.NoteOn channel 0 key 57 velocity 100
+20ms channel.NoteOn channel 0 key 64 velocity 100
+25ms channel.NoteOff channel 0 key 57
+25ms channel.NoteOff channel 0 key 60
+25ms channel.NoteOff channel 0 key 64`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
@@ -0,0 +1,33 @@
+package midigen
+
+import (
+	"time"
+)
+
+// Scheduler provides the current time and calls functions after a delay
+type Scheduler interface {
+	// AfterFunc calls f after the duration d. The returned function cancels the call,
+	// if it has not been started yet.
+	AfterFunc(d time.Duration, f func()) (cancel func())
+
+	// Now returns the current time of the scheduler
+	Now() time.Duration
+}
+
+type realTime struct{}
+
+var realTimeStart = time.Now()
+
+func (realTime) Now() time.Duration {
+	return time.Since(realTimeStart)
+}
+
+func (realTime) AfterFunc(d time.Duration, f func()) func() {
+	t := time.AfterFunc(d, f)
+	return func() {
+		t.Stop()
+	}
+}
+
+// RealTime is a Scheduler that uses the timers of the time package
+var RealTime Scheduler = realTime{}
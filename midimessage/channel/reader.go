@@ -58,16 +58,16 @@ type reader struct {
 
 // Read reads a channel message
 func (r *reader) Read(status byte, arg1 byte) (msg Message, err error) {
+	if status < 0x80 || status > 0xEF {
+		return nil, fmt.Errorf("% X is not a channel message status", status)
+	}
+
 	typ, channel := midilib.ParseStatus(status)
 
 	// fmt.Printf("typ: %v channel: %v\n", typ, channel)
 
 	// fmt.Printf("arg1: %v, err: %v\n", arg1, err)
 
-	if err != nil {
-		return
-	}
-
 	switch typ {
 
 	// one argument only
@@ -0,0 +1,91 @@
+package key
+
+import (
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// major keys by their tonic, spelled with the fewest accidentals
+var majorKeys = [12]func() meta.Key{
+	CMaj, DFlatMaj, DMaj, EFlatMaj, EMaj, FMaj, FSharpMaj, GMaj, AFlatMaj, AMaj, BFlatMaj, BMaj,
+}
+
+// minor keys by their tonic, spelled with the fewest accidentals
+var minorKeys = [12]func() meta.Key{
+	CMin, CSharpMin, DMin, EFlatMin, EMin, FMin, FSharpMin, GMin, GSharpMin, AMin, BFlatMin, BMin,
+}
+
+// Major returns the MIDI key signature meta message for the major key with the given tonic (0 = C, 1 = C#/Db ... 11 = B).
+// The key is spelled with the fewest accidentals. If there are two spellings with the same number of accidentals
+// (F# and Gb Major), preferFlat chooses the flat one.
+func Major(tonic uint8, preferFlat bool) meta.Key {
+	tonic %= 12
+
+	if tonic == 6 && preferFlat {
+		return GFlatMaj()
+	}
+
+	return majorKeys[tonic]()
+}
+
+// Minor returns the MIDI key signature meta message for the minor key with the given tonic (0 = C, 1 = C#/Db ... 11 = B).
+// The key is spelled with the fewest accidentals. If there are two spellings with the same number of accidentals
+// (D# and Eb Minor), preferFlat chooses the flat one.
+func Minor(tonic uint8, preferFlat bool) meta.Key {
+	tonic %= 12
+
+	if tonic == 3 && !preferFlat {
+		return DSharpMin()
+	}
+
+	return minorKeys[tonic]()
+}
+
+// Transpose returns the MIDI key signature meta message for the given key transposed by the given semitones.
+// The key is spelled as by Major and Minor, where flats are preferred if the given key has flats.
+func Transpose(k meta.Key, semitones int) meta.Key {
+	tonic := uint8(((int(k.Key)+semitones)%12 + 12) % 12)
+
+	if k.IsMajor {
+		return Major(tonic, k.IsFlat)
+	}
+
+	return Minor(tonic, k.IsFlat)
+}
+
+var (
+	majorScale = [7]int{0, 2, 4, 5, 7, 9, 11}
+	minorScale = [7]int{0, 2, 3, 5, 7, 8, 10}
+)
+
+// Diatonic moves the note (a MIDI key) by the given steps within the (natural major or minor) scale of the given key signature.
+// A note that is not part of the scale is moved like the next lower note of the scale and keeps its distance to it.
+func Diatonic(k meta.Key, note, steps int) int {
+	scale := majorScale
+
+	if !k.IsMajor {
+		scale = minorScale
+	}
+
+	tonic := int(k.Key)
+	octave := floorDiv(note-tonic, 12)
+	pitch := note - tonic - octave*12
+
+	// the next lower key of the scale
+	degree := 6
+	for scale[degree] > pitch {
+		degree--
+	}
+
+	chromatic := pitch - scale[degree]
+	idx := octave*7 + degree + steps
+	octave = floorDiv(idx, 7)
+
+	return tonic + octave*12 + scale[idx-octave*7] + chromatic
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
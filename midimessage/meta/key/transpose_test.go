@@ -0,0 +1,57 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		key       meta.Key
+		semitones int
+		expected  string
+	}{
+		{CMaj(), 0, "C maj."},
+		{CMaj(), 2, "D maj."},
+		{CMaj(), -2, "B♭ maj."},
+		{CMaj(), 6, "F♯ maj."},
+		{FMaj(), 1, "G♭ maj."},
+		{AFlatMaj(), -7, "D♭ maj."},
+		{GMaj(), 25, "A♭ maj."},
+		{AMin(), 3, "C min."},
+		{AMin(), -6, "D♯ min."},
+		{GMin(), -4, "E♭ min."},
+		{EMin(), -15, "C♯ min."},
+	}
+
+	for _, test := range tests {
+		if got := Transpose(test.key, test.semitones).Text(); got != test.expected {
+			t.Errorf("Transpose(%s, %v) = %s; want %s", test.key.Text(), test.semitones, got, test.expected)
+		}
+	}
+}
+
+func TestDiatonic(t *testing.T) {
+	tests := []struct {
+		key      meta.Key
+		note     int
+		steps    int
+		expected int
+	}{
+		{CMaj(), 60, 2, 64},
+		{CMaj(), 60, 7, 72},
+		{CMaj(), 60, -1, 59},
+		{CMaj(), 66, 2, 70},
+		{AMin(), 57, 2, 60},
+		{AMin(), 48, -3, 43},
+		{DMaj(), 62, 4, 69},
+		{DMaj(), 61, 1, 62},
+	}
+
+	for _, test := range tests {
+		if got := Diatonic(test.key, test.note, test.steps); got != test.expected {
+			t.Errorf("Diatonic(%s, %v, %v) = %v; want %v", test.key.Text(), test.note, test.steps, got, test.expected)
+		}
+	}
+}
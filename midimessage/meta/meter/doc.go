@@ -5,5 +5,8 @@
 /*
 Package meter provides helper functions for time signature meta messages.
 
+A Map converts absolute positions in ticks to musical positions (bar, beat and tick) and back,
+based on the time signature changes of a song.
+
 */
 package meter
@@ -0,0 +1,142 @@
+package meter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// Position is a musical position.
+// Bar and Beat start with 1, Tick is the number of ticks within the beat (starting with 0).
+// The beat is the note value of the denominator of the time signature (e.g. an eighth in 6/8).
+type Position struct {
+	Bar  uint32
+	Beat uint32
+	Tick uint32
+}
+
+// String returns the position in the form bar.beat.tick
+func (p Position) String() string {
+	return fmt.Sprintf("%v.%v.%v", p.Bar, p.Beat, p.Tick)
+}
+
+// change is a time signature change
+type change struct {
+	absTicks uint64
+	timesig  meta.TimeSig
+
+	// bar is the index of the bar that starts with the change (starting with 0)
+	bar uint64
+}
+
+// Map converts between absolute ticks and musical positions, based on the time signature changes.
+// Before the first time signature, 4/4 is assumed.
+// A time signature change that is not on a bar line starts a new bar, so the bar before is shortened.
+type Map struct {
+	resolution smf.MetricTicks
+	changes    []change
+}
+
+// NewMap returns a Map for the given resolution that has no time signature changes
+func NewMap(resolution smf.MetricTicks) *Map {
+	m := &Map{resolution: resolution}
+	m.changes = []change{{timesig: M4_4()}}
+	return m
+}
+
+// Add adds the time signature at the given position. It replaces a time signature at the same position.
+// Time signatures may be added in any order.
+func (m *Map) Add(absTicks uint64, timesig meta.TimeSig) {
+	if timesig.Numerator == 0 || timesig.Denominator == 0 {
+		return
+	}
+
+	i := sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].absTicks >= absTicks
+	})
+
+	if i < len(m.changes) && m.changes[i].absTicks == absTicks {
+		m.changes[i].timesig = timesig
+	} else {
+		m.changes = append(m.changes[:i], append([]change{{absTicks: absTicks, timesig: timesig}}, m.changes[i:]...)...)
+	}
+
+	m.countBars()
+}
+
+// countBars sets the first bar of each change
+func (m *Map) countBars() {
+	for i := 1; i < len(m.changes); i++ {
+		prev := m.changes[i-1]
+		barTicks := m.barTicks(prev.timesig)
+
+		// an incomplete bar counts as a bar
+		m.changes[i].bar = prev.bar + (m.changes[i].absTicks-prev.absTicks+barTicks-1)/barTicks
+	}
+}
+
+func (m *Map) beatTicks(timesig meta.TimeSig) uint64 {
+	t := uint64(m.resolution.Ticks4th()) * 4 / uint64(timesig.Denominator)
+
+	// the resolution is too low for the beat
+	if t == 0 {
+		return 1
+	}
+
+	return t
+}
+
+func (m *Map) barTicks(timesig meta.TimeSig) uint64 {
+	return m.beatTicks(timesig) * uint64(timesig.Numerator)
+}
+
+// TimeSig returns the time signature at the given position
+func (m *Map) TimeSig(absTicks uint64) meta.TimeSig {
+	return m.changes[m.changeAt(absTicks)].timesig
+}
+
+// changeAt returns the index of the last change at or before the given position
+func (m *Map) changeAt(absTicks uint64) int {
+	return sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].absTicks > absTicks
+	}) - 1
+}
+
+// Position returns the musical position of the given absolute position
+func (m *Map) Position(absTicks uint64) Position {
+	c := m.changes[m.changeAt(absTicks)]
+	beatTicks := m.beatTicks(c.timesig)
+	barTicks := m.barTicks(c.timesig)
+	d := absTicks - c.absTicks
+
+	return Position{
+		Bar:  uint32(c.bar + d/barTicks + 1),
+		Beat: uint32(d%barTicks/beatTicks + 1),
+		Tick: uint32(d % beatTicks),
+	}
+}
+
+// Ticks returns the absolute position of the given musical position.
+// It returns an error, if the bar or beat is 0 or the beat or tick does not exist in the time signature of the bar.
+func (m *Map) Ticks(p Position) (uint64, error) {
+	if p.Bar == 0 || p.Beat == 0 {
+		return 0, fmt.Errorf("invalid position %s: bar and beat start with 1", p)
+	}
+
+	bar := uint64(p.Bar - 1)
+
+	i := sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].bar > bar
+	}) - 1
+
+	c := m.changes[i]
+	beatTicks := m.beatTicks(c.timesig)
+
+	if p.Beat > uint32(c.timesig.Numerator) || uint64(p.Tick) >= beatTicks {
+		return 0, fmt.Errorf("invalid position %s for time signature %v/%v", p, c.timesig.Numerator, c.timesig.Denominator)
+	}
+
+	return c.absTicks + (bar-c.bar)*m.barTicks(c.timesig) + uint64(p.Beat-1)*beatTicks + uint64(p.Tick), nil
+}
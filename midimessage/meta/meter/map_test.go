@@ -0,0 +1,69 @@
+package meter
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/smf"
+)
+
+func TestMap(t *testing.T) {
+	m := NewMap(smf.MetricTicks(96))
+
+	// two bars 4/4, then 6/8 from 768, a shortened 6/8 bar and 3/4 from 1200
+	m.Add(1200, M3_4())
+	m.Add(768, M6_8())
+
+	tests := []struct {
+		absTicks uint64
+		expected string
+	}{
+		{0, "1.1.0"},
+		{95, "1.1.95"},
+		{96, "1.2.0"},
+		{420, "2.1.36"},
+		{612, "2.3.36"},
+		{768, "3.1.0"},
+		{816, "3.2.0"},
+		{1055, "3.6.47"},
+		{1056, "4.1.0"},
+		{1152, "4.3.0"},
+		{1200, "5.1.0"},
+		{1200 + 288 + 100, "6.2.4"},
+	}
+
+	for _, test := range tests {
+		p := m.Position(test.absTicks)
+
+		if got := p.String(); got != test.expected {
+			t.Errorf("Position(%v) = %s; want %s", test.absTicks, got, test.expected)
+			continue
+		}
+
+		if abs, err := m.Ticks(p); err != nil || abs != test.absTicks {
+			t.Errorf("Ticks(%s) = %v, %v; want %v", p, abs, err, test.absTicks)
+		}
+	}
+
+	if got := m.TimeSig(1000).String(); got != M6_8().String() {
+		t.Errorf("TimeSig(1000) = %s; want %s", got, M6_8())
+	}
+
+	invalid := []Position{{0, 1, 0}, {1, 0, 0}, {1, 5, 0}, {3, 7, 0}, {3, 1, 48}}
+
+	for _, p := range invalid {
+		if _, err := m.Ticks(p); err == nil {
+			t.Errorf("Ticks(%s) must return an error", p)
+		}
+	}
+}
+
+func TestMapReplace(t *testing.T) {
+	m := NewMap(smf.MetricTicks(96))
+	m.Add(0, M3_4())
+	m.Add(288, M2_4())
+	m.Add(288, M5_8())
+
+	if got := m.Position(288 + 192).String(); got != "2.5.0" {
+		t.Errorf("Position = %s; want 2.5.0", got)
+	}
+}
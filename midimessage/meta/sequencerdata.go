@@ -47,13 +47,7 @@ func (s SequencerData) meta() {
 }
 
 func (s SequencerData) readFrom(rd io.Reader) (Message, error) {
-	length, err := midilib.ReadVarLength(rd)
-
-	if err != nil {
-		return nil, err
-	}
-
-	bt, err := midilib.ReadNBytes(int(length), rd)
+	bt, err := midilib.ReadVarLengthData(rd)
 
 	if err != nil {
 		return nil, err
@@ -13,7 +13,7 @@ func TestTempo(t *testing.T) {
 	tt, err := tm.readFrom(bytes.NewBuffer(bt))
 
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 
 	ttt := tt.(Tempo)
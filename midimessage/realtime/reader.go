@@ -1,6 +1,7 @@
 package realtime
 
 import (
+	"bufio"
 	"io"
 )
 
@@ -8,50 +9,71 @@ import (
 // every realtime.Reader is an io.Reader but not every io.Reader is a realtime.Reader
 type Reader interface {
 	io.Reader
+	io.ByteReader
 	realtime()
 }
 
 // NewReader returns an io.Reader that filters realtime midi messages.
 // For each realtime midi message, rthandler is called (if it is not nil)
-// The Reader does no buffering and makes no attempt to close input.
+// The Reader makes no attempt to close input.
+//
+// If input is an io.ByteReader, it is read byte by byte without any allocations.
+// Otherwise it is wrapped inside a bufio.Reader, so that more bytes may be read from input
+// than have been returned by the Reader.
 func NewReader(input io.Reader, rthandler func(Message)) Reader {
+	br, is := input.(io.ByteReader)
+	if !is {
+		br = bufio.NewReader(input)
+	}
+
 	if rthandler == nil {
-		return &discardReader{input}
+		return &discardReader{br}
 	}
-	return &reader{input, rthandler}
+	return &reader{br, rthandler}
 }
 
-func (r *reader) Read(target []byte) (n int, err error) {
-	var bf = make([]byte, 1)
-
+// ReadByte reads the next byte that is not part of a realtime message
+func (r *reader) ReadByte() (b byte, err error) {
 	for {
-		if n == len(target) {
-			return
-
-		}
-
 		// error needed here to be able to interrupt the reading from the callback (handler)
 		// then an io.EOF error is returned and propagated to midireader.read()
-		_, err = r.input.Read(bf)
+		b, err = r.input.ReadByte()
 
 		if err != nil {
 			return
 		}
 
 		// => no realtime message
-		if bf[0] < 0xF8 {
-			target[n] = bf[0]
-			n++
-			continue
+		if b < 0xF8 {
+			return
 		}
 
-		if m := dispatch(bf[0]); m != nil {
+		if m := dispatch(b); m != nil {
 			// we know that r.handler is not nil (otherwise we would be inside discardReader)
 			r.handler(m)
 		}
 	}
 }
 
+func (r *reader) Read(target []byte) (n int, err error) {
+	return readBytes(r, target)
+}
+
+// readBytes fills target by calling ReadByte
+func readBytes(br io.ByteReader, target []byte) (n int, err error) {
+	for n < len(target) {
+		target[n], err = br.ReadByte()
+
+		if err != nil {
+			return
+		}
+
+		n++
+	}
+
+	return
+}
+
 /*
     Each RealTime Category message (ie, Status of 0xF8 to 0xFF) consists of only 1 byte, the Status.
     These messages are primarily concerned with timing/syncing functions which means that they
@@ -65,10 +87,10 @@ func (r *reader) Read(target []byte) (n int, err error) {
 For more information about RealTime, read the sections Running Status, Ignoring MIDI Messages, and Syncing Sequence Playback.
 */
 
-// reader is is a wrapper around an io.Reader that filters realtime midi events
+// reader is is a wrapper around an io.ByteReader that filters realtime midi events
 // when reading it calls Callback for every realtime event and reading everything else into the target buffer
 type reader struct {
-	input   io.Reader
+	input   io.ByteReader
 	handler func(Message)
 }
 
@@ -76,38 +98,31 @@ func (r *reader) realtime() {}
 
 // discardReader is an optimized reader that discards realtime messages
 type discardReader struct {
-	input io.Reader
+	input io.ByteReader
 }
 
 func (r *discardReader) realtime() {}
 
 func (r *discardReader) Read(target []byte) (n int, err error) {
-	var bf []byte
+	return readBytes(r, target)
+}
 
+// ReadByte reads the next byte that is not part of a realtime message
+func (r *discardReader) ReadByte() (b byte, err error) {
 	for {
-		if n == len(target) {
-			return
-
-		}
-		bf = make([]byte, 1)
-
-		_, err = r.input.Read(bf)
+		b, err = r.input.ReadByte()
 
 		if err != nil {
 			return
 		}
 
 		// => no realtime message
-		if bf[0] < 0xF8 {
-			target[n] = bf[0]
-			n++
-			continue
+		if b < 0xF8 {
+			return
 		}
 
 		// don't handle realtime messages, so do nothing here
-
 	}
-
 }
 
 func dispatch(b byte) Message {
@@ -3,6 +3,7 @@ package realtime_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/gomidi/midi"
@@ -129,3 +130,49 @@ func TestRead(t *testing.T) {
 	}
 
 }
+
+type plainReader struct {
+	data []byte
+}
+
+func (p *plainReader) Read(b []byte) (int, error) {
+	if len(p.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, p.data)
+	p.data = p.data[n:]
+	return n, nil
+}
+
+func TestReadByte(t *testing.T) {
+	// timing clock between the data bytes of a noteon
+	input := []byte{0x91, 0x41, 0xF8, 0x64, 0xFA}
+
+	for _, src := range []io.Reader{bytes.NewReader(input), &plainReader{input}} {
+		var rtout bytes.Buffer
+		rd := realtime.NewReader(src, func(ev realtime.Message) {
+			rtout.WriteString(ev.String() + "\n")
+		})
+
+		var out []byte
+
+		for {
+			b, err := rd.ReadByte()
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("unexpected error: %v", err)
+				}
+				break
+			}
+			out = append(out, b)
+		}
+
+		if got, want := fmt.Sprintf("% X", out), "91 41 64"; got != want {
+			t.Errorf("%T: got %q; want %q", src, got, want)
+		}
+
+		if got, want := rtout.String(), "TimingClock\nStart\n"; got != want {
+			t.Errorf("%T: got %q (rtoutput); want %q", src, got, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midinote pairs note on and note off messages to notes with a duration.
+
+A note off is a channel.NoteOff, a channel.NoteOffVelocity or a channel.NoteOn with velocity 0.
+Options define how overlapping notes of the same key are paired, what happens to notes that are not
+ended at the end of a track and whether the sustain pedal extends the duration of the notes.
+
+Usage
+
+	import (
+		"github.com/gomidi/midi/midinote"
+		"github.com/gomidi/midi/smf/smftrack"
+	)
+
+	s, err := smftrack.ReadFile("song.mid")
+
+	if err != nil {
+		// handle error
+	}
+
+	for _, n := range midinote.FromSMF(s, midinote.SustainPedal()) {
+		fmt.Printf("track %v key %v at %v for %v ticks\n", n.Track, n.Key, n.StartTick, n.Duration)
+	}
+
+For live streams, an Extractor is fed with the messages and their positions (e.g. in milliseconds)
+and returns the notes, as soon as they are complete.
+
+*/
+package midinote
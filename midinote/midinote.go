@@ -0,0 +1,292 @@
+package midinote
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// Note is a note with a duration
+type Note struct {
+	// Track is the track of the note (starting with 0)
+	Track int
+
+	// Channel is the MIDI channel of the note (0-15)
+	Channel uint8
+
+	// Key is the key of the note
+	Key uint8
+
+	// Velocity is the velocity of the note on message
+	Velocity uint8
+
+	// ReleaseVelocity is the velocity of the note off message (0, if it has none)
+	ReleaseVelocity uint8
+
+	// StartTick is the position of the note on message
+	StartTick uint64
+
+	// Duration is the distance between the note on message and the end of the note
+	Duration uint64
+}
+
+// Option is an option for the Extractor
+type Option func(*Extractor)
+
+// LIFO lets a note off end the last started note of the same key, track and channel.
+// Without passing this option, the first started note is ended (FIFO).
+func LIFO() Option {
+	return func(e *Extractor) {
+		e.lifo = true
+	}
+}
+
+// DropHanging drops the notes that have not been ended when the track ends.
+// Without passing this option, those notes end at the end of the track.
+func DropHanging() Option {
+	return func(e *Extractor) {
+		e.dropHanging = true
+	}
+}
+
+// SustainPedal lets notes that are released while the sustain pedal (controller 64) is down end,
+// when the pedal is released or the key is struck again.
+// Without passing this option, the sustain pedal is ignored.
+func SustainPedal() Option {
+	return func(e *Extractor) {
+		e.sustain = true
+	}
+}
+
+type noteKey struct {
+	track   int
+	channel uint8
+	key     uint8
+}
+
+type channelKey struct {
+	track   int
+	channel uint8
+}
+
+// Extractor pairs note on and note off messages to notes
+type Extractor struct {
+	lifo        bool
+	dropHanging bool
+	sustain     bool
+
+	// notes that have been started and not ended
+	open map[noteKey][]Note
+
+	// notes that have been ended while the sustain pedal was down
+	sustained map[noteKey][]Note
+
+	// channels with the sustain pedal down
+	pedal map[channelKey]bool
+}
+
+// New returns an Extractor
+func New(options ...Option) *Extractor {
+	e := &Extractor{
+		open:      map[noteKey][]Note{},
+		sustained: map[noteKey][]Note{},
+		pedal:     map[channelKey]bool{},
+	}
+
+	for _, opt := range options {
+		opt(e)
+	}
+
+	return e
+}
+
+// Add adds the message of the given track at the given position and returns the notes that are complete.
+// The positions must not decrease within a track. Messages that are not relevant for notes are ignored.
+// Note offs without a started note are ignored too.
+// The controllers "All Sound Off" (120) and "All Notes Off" (123) end all notes of the channel.
+func (e *Extractor) Add(track int, pos uint64, msg midi.Message) (notes []Note) {
+	switch m := msg.(type) {
+	case channel.NoteOn:
+		if m.Velocity() == 0 {
+			return e.noteOff(noteKey{track, m.Channel(), m.Key()}, pos, 0)
+		}
+
+		k := noteKey{track, m.Channel(), m.Key()}
+
+		// striking the key again ends the sustained note
+		notes = e.end(e.sustained[k], pos)
+		delete(e.sustained, k)
+
+		e.open[k] = append(e.open[k], Note{
+			Track:     track,
+			Channel:   m.Channel(),
+			Key:       m.Key(),
+			Velocity:  m.Velocity(),
+			StartTick: pos,
+		})
+		return notes
+	case channel.NoteOff:
+		return e.noteOff(noteKey{track, m.Channel(), m.Key()}, pos, 0)
+	case channel.NoteOffVelocity:
+		return e.noteOff(noteKey{track, m.Channel(), m.Key()}, pos, m.Velocity())
+	case channel.ControlChange:
+		ch := channelKey{track, m.Channel()}
+
+		switch m.Controller() {
+		case 64:
+			if !e.sustain {
+				return nil
+			}
+
+			e.pedal[ch] = m.Value() >= 64
+
+			if !e.pedal[ch] {
+				return e.release(ch, pos)
+			}
+		case 120:
+			notes = append(e.allNotesOff(ch, pos), e.release(ch, pos)...)
+			sortNotes(notes)
+			return notes
+		case 123:
+			return e.allNotesOff(ch, pos)
+		}
+	}
+
+	return nil
+}
+
+// noteOff ends a note of the given key
+func (e *Extractor) noteOff(k noteKey, pos uint64, velocity uint8) []Note {
+	open := e.open[k]
+
+	if len(open) == 0 {
+		return nil
+	}
+
+	var n Note
+
+	if e.lifo {
+		n, e.open[k] = open[len(open)-1], open[:len(open)-1]
+	} else {
+		n, e.open[k] = open[0], open[1:]
+	}
+
+	if len(e.open[k]) == 0 {
+		delete(e.open, k)
+	}
+
+	n.ReleaseVelocity = velocity
+
+	if e.pedal[channelKey{k.track, k.channel}] {
+		e.sustained[k] = append(e.sustained[k], n)
+		return nil
+	}
+
+	return e.end([]Note{n}, pos)
+}
+
+// allNotesOff ends all open notes of the channel, the sustain pedal is respected
+func (e *Extractor) allNotesOff(ch channelKey, pos uint64) (notes []Note) {
+	for k := range e.open {
+		if k.track != ch.track || k.channel != ch.channel {
+			continue
+		}
+
+		for len(e.open[k]) > 0 {
+			notes = append(notes, e.noteOff(k, pos, 0)...)
+		}
+	}
+
+	sortNotes(notes)
+	return
+}
+
+// release ends the sustained notes of the channel
+func (e *Extractor) release(ch channelKey, pos uint64) (notes []Note) {
+	for k, sustained := range e.sustained {
+		if k.track == ch.track && k.channel == ch.channel {
+			notes = append(notes, e.end(sustained, pos)...)
+			delete(e.sustained, k)
+		}
+	}
+
+	sortNotes(notes)
+	return
+}
+
+// end sets the durations of the notes
+func (e *Extractor) end(notes []Note, pos uint64) []Note {
+	for i := range notes {
+		notes[i].Duration = pos - notes[i].StartTick
+	}
+	return notes
+}
+
+// End ends the given track at the given position and returns the notes that are still open or sustained
+// (see DropHanging).
+func (e *Extractor) End(track int, pos uint64) (notes []Note) {
+	for k, sustained := range e.sustained {
+		if k.track == track {
+			notes = append(notes, e.end(sustained, pos)...)
+			delete(e.sustained, k)
+		}
+	}
+
+	for k, open := range e.open {
+		if k.track != track {
+			continue
+		}
+
+		if !e.dropHanging {
+			notes = append(notes, e.end(open, pos)...)
+		}
+
+		delete(e.open, k)
+	}
+
+	for ch := range e.pedal {
+		if ch.track == track {
+			delete(e.pedal, ch)
+		}
+	}
+
+	sortNotes(notes)
+	return
+}
+
+// sortNotes sorts the notes by track, start, channel and key
+func sortNotes(notes []Note) {
+	sort.SliceStable(notes, func(a, b int) bool {
+		na, nb := notes[a], notes[b]
+
+		switch {
+		case na.Track != nb.Track:
+			return na.Track < nb.Track
+		case na.StartTick != nb.StartTick:
+			return na.StartTick < nb.StartTick
+		case na.Channel != nb.Channel:
+			return na.Channel < nb.Channel
+		default:
+			return na.Key < nb.Key
+		}
+	})
+}
+
+// FromSMF returns the notes of all tracks of the given SMF, sorted by track, start, channel and key.
+// The tracks end at their EndTicks.
+func FromSMF(s *smftrack.SMF, options ...Option) (notes []Note) {
+	e := New(options...)
+
+	for i, t := range s.Tracks {
+		for _, ev := range t.Events {
+			notes = append(notes, e.Add(i, ev.AbsTicks, ev.Message)...)
+		}
+
+		notes = append(notes, e.End(i, t.EndTicks())...)
+	}
+
+	sortNotes(notes)
+	return
+}
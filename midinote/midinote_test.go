@@ -0,0 +1,163 @@
+package midinote
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+type event struct {
+	pos uint64
+	msg midi.Message
+}
+
+func dump(notes []Note) string {
+	var bf bytes.Buffer
+	for _, n := range notes {
+		fmt.Fprintf(&bf, "%v/%v %v %v-%v @%v+%v\n", n.Track, n.Channel, n.Key, n.Velocity, n.ReleaseVelocity, n.StartTick, n.Duration)
+	}
+	return bf.String()
+}
+
+func extract(events []event, end uint64, options ...Option) []Note {
+	e := New(options...)
+	var notes []Note
+
+	for _, ev := range events {
+		notes = append(notes, e.Add(0, ev.pos, ev.msg)...)
+	}
+
+	return append(notes, e.End(0, end)...)
+}
+
+func TestExtractor(t *testing.T) {
+	ch := channel.Channel2
+
+	overlapping := []event{
+		{0, ch.NoteOn(60, 100)},
+		{10, ch.NoteOn(60, 90)},
+		{20, ch.NoteOffVelocity(60, 30)},
+		{30, ch.NoteOn(60, 0)},
+	}
+
+	sustained := []event{
+		{0, ch.NoteOn(60, 100)},
+		{0, ch.NoteOn(64, 100)},
+		{5, ch.ControlChange(64, 127)},
+		{10, ch.NoteOff(60)},
+		{15, ch.NoteOff(64)},
+		{20, ch.NoteOn(64, 80)},
+		{25, ch.NoteOff(64)},
+		{40, ch.ControlChange(64, 0)},
+	}
+
+	tests := []struct {
+		descr    string
+		events   []event
+		options  []Option
+		expected string
+	}{
+		{
+			"fifo",
+			overlapping,
+			nil,
+			`0/2 60 100-30 @0+20
+0/2 60 90-0 @10+20
+`,
+		},
+		{
+			"lifo",
+			overlapping,
+			[]Option{LIFO()},
+			`0/2 60 90-30 @10+10
+0/2 60 100-0 @0+30
+`,
+		},
+		{
+			"hanging notes end at the end",
+			[]event{{0, ch.NoteOn(60, 100)}, {10, ch.NoteOn(62, 100)}, {20, ch.NoteOff(60)}, {30, ch.NoteOff(61)}},
+			nil,
+			`0/2 60 100-0 @0+20
+0/2 62 100-0 @10+90
+`,
+		},
+		{
+			"hanging notes dropped",
+			[]event{{0, ch.NoteOn(60, 100)}, {10, ch.NoteOn(62, 100)}, {20, ch.NoteOff(60)}},
+			[]Option{DropHanging()},
+			`0/2 60 100-0 @0+20
+`,
+		},
+		{
+			"sustain pedal ignored",
+			sustained,
+			nil,
+			`0/2 60 100-0 @0+10
+0/2 64 100-0 @0+15
+0/2 64 80-0 @20+5
+`,
+		},
+		{
+			"sustain pedal",
+			sustained,
+			[]Option{SustainPedal()},
+			`0/2 64 100-0 @0+20
+0/2 60 100-0 @0+40
+0/2 64 80-0 @20+20
+`,
+		},
+		{
+			"sustained at the end",
+			[]event{{0, ch.ControlChange(64, 127)}, {0, ch.NoteOn(60, 100)}, {10, ch.NoteOff(60)}},
+			[]Option{SustainPedal(), DropHanging()},
+			`0/2 60 100-0 @0+100
+`,
+		},
+		{
+			"all notes off",
+			[]event{{0, ch.NoteOn(62, 100)}, {0, ch.NoteOn(60, 100)}, {5, channel.Channel3.NoteOn(60, 100)}, {10, ch.ControlChange(123, 0)}},
+			[]Option{DropHanging()},
+			`0/2 60 100-0 @0+10
+0/2 62 100-0 @0+10
+`,
+		},
+	}
+
+	for _, test := range tests {
+		got := dump(extract(test.events, 100, test.options...))
+
+		if got != test.expected {
+			t.Errorf("[%s] got:\n%s\nwanted:\n%s", test.descr, got, test.expected)
+		}
+	}
+}
+
+func TestFromSMF(t *testing.T) {
+	var t0, t1 smftrack.Track
+
+	t0.Add(0, channel.Channel0.NoteOn(60, 100))
+	t0.Add(96, channel.Channel0.NoteOff(60), channel.Channel0.NoteOn(62, 90))
+	t0.Add(192, channel.Channel0.NoteOff(62))
+
+	t1.Add(48, channel.Channel1.NoteOn(40, 70))
+	t1.Add(48, channel.Channel9.NoteOn(36, 120))
+	t1.Add(50, channel.Channel9.NoteOff(36))
+	t1.End = 384
+
+	s := &smftrack.SMF{Format: smf.SMF1, TimeFormat: smf.MetricTicks(96), Tracks: []*smftrack.Track{&t0, &t1}}
+
+	expected := `0/0 60 100-0 @0+96
+0/0 62 90-0 @96+96
+1/1 40 70-0 @48+336
+1/9 36 120-0 @48+2
+`
+
+	if got := dump(FromSMF(s)); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"io"
 	"testing"
 
+	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/realtime"
 	"github.com/gomidi/midi/midiwriter"
 )
 
@@ -117,3 +119,132 @@ func BenchmarkNoteOnOffAlternatingChannel1000(b *testing.B) {
 	}
 
 }
+
+func interleavedRealtime() io.Reader {
+	var bf bytes.Buffer
+
+	wr := midiwriter.New(&bf)
+
+	var (
+		m1 = channel.Channel1.NoteOn(20, 100)
+		m2 = channel.Channel1.NoteOff(20)
+	)
+
+	wr.Write(m1)
+	wr.Write(realtime.TimingClock)
+	wr.Write(m2)
+	wr.Write(realtime.TimingClock)
+
+	// a timing clock in between the data bytes of a noteon message
+	b := bf.Bytes()
+	b = append(b, 0x91, 0x17, byte(realtime.TimingClock), 0x46)
+
+	return &testreader{0, b}
+}
+
+// BenchmarkRealtimeInterleaved1000 reads 1000 channel messages per iteration
+// which have realtime timing clock messages interleaved, also between data bytes.
+// the realtime messages are passed to a handler
+func BenchmarkRealtimeInterleaved1000(b *testing.B) {
+	b.StopTimer()
+
+	var clocks int
+	src := interleavedRealtime()
+	rd := New(src, func(realtime.Message) { clocks++ })
+
+	var err error
+
+	b.ReportAllocs()
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 1000; j++ {
+			_, err = rd.Read()
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	}
+
+}
+
+// BenchmarkByteReader1000 reads 1000 channel messages per iteration
+// from a source that is an io.ByteReader (and therefore is not buffered by the reader).
+func BenchmarkByteReader1000(b *testing.B) {
+	b.StopTimer()
+
+	var bf bytes.Buffer
+	wr := midiwriter.New(&bf)
+
+	for j := 0; j < 1000; j++ {
+		wr.Write(channel.Channel1.NoteOn(20, 100))
+		wr.Write(channel.Channel4.NoteOff(20))
+	}
+
+	data := bf.Bytes()
+
+	var err error
+
+	b.ReportAllocs()
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		rd := New(bytes.NewReader(data), nil)
+		for j := 0; j < 1000; j++ {
+			_, err = rd.Read()
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	}
+
+}
+
+// BenchmarkReadNoteOnOffSameChannel1000 is like BenchmarkNoteOnOffSameChannel1000 but reports the allocations,
+// to be compared with BenchmarkReadEventNoteOnOffSameChannel1000
+func BenchmarkReadNoteOnOffSameChannel1000(b *testing.B) {
+	b.StopTimer()
+
+	src := sameChannel()
+	rd := New(src, nil)
+
+	var err error
+
+	b.ReportAllocs()
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 1000; j++ {
+			_, err = rd.Read()
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	}
+
+}
+
+// BenchmarkReadEventNoteOnOffSameChannel1000 is like BenchmarkNoteOnOffSameChannel1000 but reads
+// via ReadEvent into a reused midi.Event
+func BenchmarkReadEventNoteOnOffSameChannel1000(b *testing.B) {
+	b.StopTimer()
+
+	src := sameChannel()
+	rd := New(src, nil).(midi.EventReader)
+
+	var ev midi.Event
+	var err error
+
+	b.ReportAllocs()
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 1000; j++ {
+			err = rd.ReadEvent(&ev)
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	}
+
+}
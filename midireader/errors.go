@@ -0,0 +1,5 @@
+package midireader
+
+import "errors"
+
+var errUnexpectedEndOfSysEx = errors.New("unexpected end of sysex (F7) without start of sysex")
@@ -0,0 +1,144 @@
+package midireader
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/midilib"
+)
+
+var _ midi.EventReader = &reader{}
+
+// ReadEvent reads the next MIDI message into ev. It is an alternative to Read that does not allocate
+// (apart from growing ev.Payload for sysex messages).
+// Errors are returned as *midi.ReadError, apart from io.EOF which is returned unchanged.
+// Realtime messages are passed to the rthandler as with Read.
+func (r *reader) ReadEvent(ev *midi.Event) (err error) {
+	// read the canary in the coal mine to see, if we have a running status byte or a given one
+	var canary byte
+	canary, err = midilib.ReadByte(r.input)
+
+	if err != nil {
+		return r.newError(err)
+	}
+
+	for {
+		var known bool
+		known, err = r.readSingleEvent(canary, ev)
+
+		if err != nil || known {
+			return r.newError(err)
+		}
+
+		// unknown event: read until next status byte
+		canary, err = r.discardUntilNextStatus()
+		if err != nil {
+			return r.newError(err)
+		}
+	}
+}
+
+// readSingleEvent reads the MIDI message that started with canary into ev.
+// known is false for unknown messages.
+func (r *reader) readSingleEvent(canary byte, ev *midi.Event) (known bool, err error) {
+	// the canary is the last byte that has been read
+	r.msgOffset = r.counter.N - 1
+	status, changed := r.runningStatus.Read(canary)
+
+	r.status = status
+	if status == 0 {
+		r.status = canary
+	}
+
+	// on a voice/channel message, status came directly or from running status
+	if status != 0 {
+		var arg1 = canary // assume running status - we already got arg1
+
+		// was no running status, we have to read arg1
+		if changed {
+			arg1, err = midilib.ReadByte(r.input)
+			if err != nil {
+				return
+			}
+		}
+
+		return true, midilib.ReadChannelEvent(ev, status, arg1, r.input)
+	}
+
+	ev.Status = canary
+	ev.Channel = 0
+	ev.Data1 = 0
+	ev.Data2 = 0
+
+	switch canary {
+
+	/* start sysex */
+	case 0xF0:
+		ev.Kind = midi.KindSysEx
+		ev.Payload, status, err = r.readSysExInto(ev.Payload[:0])
+
+		// see readSingleMsg
+		if status != 0 {
+			r.runningStatus.Read(status)
+		}
+		return true, err
+
+	case 0xF7:
+		// we should never have a 0xF7 since sysex must already have consumed it
+		return false, errUnexpectedEndOfSysEx
+
+	// MIDI Timing Code, Song Select
+	case 0xF1, 0xF3:
+		ev.Kind = midi.KindSysCommon
+		ev.Data1, err = midilib.ReadByte(r.input)
+		return true, err
+
+	// Song Position Pointer
+	case 0xF2:
+		ev.Kind = midi.KindSysCommon
+		ev.Data1, err = midilib.ReadByte(r.input)
+		if err != nil {
+			return
+		}
+		ev.Data2, err = midilib.ReadByte(r.input)
+		return true, err
+
+	// Tune Request
+	case 0xF6:
+		ev.Kind = midi.KindSysCommon
+		return true, nil
+
+	default:
+		// undefined system common messages and data bytes without status
+		return false, nil
+	}
+}
+
+// readSysExInto reads a sysex like readSysEx but appends the data (and a terminating 0xF7) to bf
+func (r *reader) readSysExInto(bf []byte) (data []byte, status byte, err error) {
+	var b byte
+
+	// read byte by byte
+	for {
+		b, err = midilib.ReadByte(r.input)
+		if err != nil {
+			break
+		}
+
+		// the normal way to terminate
+		if b == byte(0xF7) {
+			break
+		}
+
+		// not so elegant way to terminate by sending a new status
+		if midilib.IsStatusByte(b) {
+			status = b
+			break
+		}
+
+		bf = append(bf, b)
+	}
+
+	// any error, especially io.EOF is considered a failure.
+	// however return the sysex that had been received so far back to the user
+	// and leave him to decide what to do.
+	return append(bf, 0xF7), status, err
+}
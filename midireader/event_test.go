@@ -0,0 +1,65 @@
+package midireader
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+func TestReadEvent(t *testing.T) {
+	data, _ := ioutil.ReadAll(mkMIDI())
+
+	var rts, evrts []realtime.Message
+
+	rd := New(bytes.NewReader(data), func(m realtime.Message) { rts = append(rts, m) }, NoteOffVelocity())
+	evrd := New(bytes.NewReader(data), func(m realtime.Message) { evrts = append(evrts, m) }).(midi.EventReader)
+
+	var ev midi.Event
+
+	for i := 0; ; i++ {
+		m, err := rd.Read()
+		everr := evrd.ReadEvent(&ev)
+
+		if err != everr {
+			t.Fatalf("[%v] ReadEvent returned error %v, Read returned %v", i, everr, err)
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("[%v] unexpected error: %v", i, err)
+		}
+
+		if got, expected := ev.AppendRaw(nil), m.Raw(); !bytes.Equal(got, expected) {
+			t.Errorf("[%v] ReadEvent got % X, Read got % X (%s)", i, got, expected, m)
+		}
+	}
+
+	if len(rts) != len(evrts) {
+		t.Errorf("realtime messages differ: ReadEvent got %v, Read got %v", evrts, rts)
+	}
+}
+
+func TestReadEventAllocs(t *testing.T) {
+	rd := New(alternatingChannel(), nil).(midi.EventReader)
+
+	var ev midi.Event
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for j := 0; j < 1000; j++ {
+			if err := rd.ReadEvent(&ev); err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("ReadEvent allocates %v times per 1000 messages, expected 0", allocs)
+	}
+}
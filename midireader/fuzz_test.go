@@ -0,0 +1,36 @@
+//go:build go1.18
+// +build go1.18
+
+package midireader
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+// FuzzRead makes sure, that no input can make the reader panic or loop forever.
+func FuzzRead(f *testing.F) {
+	seed, _ := ioutil.ReadAll(mkMIDI())
+	f.Add(seed)
+	f.Add([]byte{0xF7, 0x90, 0x40, 0x40})
+	f.Add([]byte{0xF4, 0xF5, 0x01, 0xF8, 0xE0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rd := New(bytes.NewReader(data), func(realtime.Message) {})
+
+		var err error
+
+		// every message consumes at least one byte
+		for i := 0; i <= len(data); i++ {
+			_, err = rd.Read()
+			if err != nil {
+				return
+			}
+		}
+
+		t.Fatalf("reading did not stop after %v messages", len(data)+1)
+	})
+}
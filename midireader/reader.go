@@ -1,6 +1,7 @@
 package midireader
 
 import (
+	"bufio"
 	"io"
 
 	"github.com/gomidi/midi"
@@ -16,11 +17,22 @@ import (
 // When calling Read, any intermediate System Realtime Message will be either ignored (if rthandler is nil)
 // or passed to rthandler (if not) while other MIDI messages will be returned.
 //
-// The Reader does no buffering and makes no attempt to close src.
+// The Reader makes no attempt to close src.
 // If src.Read returns an io.EOF, the reader stops reading and returns the error.
+//
+// If src is an io.ByteReader, it is read byte by byte. Otherwise src is wrapped inside a bufio.Reader
+// to avoid a read call per byte. Then more bytes may be read from src than belong to the messages that have been returned.
+//
+// The returned reader also implements midi.EventReader for reading without allocations.
 func New(src io.Reader, rthandler func(realtime.Message), options ...Option) midi.Reader {
+	if _, is := src.(io.ByteReader); !is {
+		src = bufio.NewReader(src)
+	}
+
+	counter := &midilib.Counter{Reader: src}
 	rd := &reader{
-		input:         realtime.NewReader(src, rthandler),
+		counter:       counter,
+		input:         realtime.NewReader(counter, rthandler),
 		runningStatus: runningstatus.NewLiveReader(),
 	}
 
@@ -39,23 +51,45 @@ func New(src io.Reader, rthandler func(realtime.Message), options ...Option) mid
 }
 
 type reader struct {
+	counter             *midilib.Counter
 	input               realtime.Reader
 	runningStatus       runningstatus.Reader
 	channelReader       channel.Reader
 	readNoteOffPedantic bool
+
+	// position of the current message, used for errors
+	msgOffset int64
+	status    byte
 }
 
 // Read reads the next MIDI mesage.
+// Errors are returned as *midi.ReadError, apart from io.EOF which is returned unchanged.
 func (r *reader) Read() (msg midi.Message, err error) {
 	// read the canary in the coal mine to see, if we have a running status byte or a given one
 	var canary byte
 	canary, err = midilib.ReadByte(r.input)
 
 	if err != nil {
-		return
+		return nil, r.newError(err)
+	}
+
+	msg, err = r.readMsg(canary)
+	return msg, r.newError(err)
+}
+
+// newError wraps the given error inside a *midi.ReadError that carries the position of the current message.
+// io.EOF is returned unchanged.
+func (r *reader) newError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
 	}
 
-	return r.readMsg(canary)
+	return &midi.ReadError{
+		Offset: r.msgOffset,
+		Track:  -1,
+		Status: r.status,
+		Err:    err,
+	}
 }
 
 // discardUntilNextStatus discards every byte until the next status byte
@@ -130,9 +164,35 @@ func (r *reader) readSysEx() (sys sysex.SysEx, status byte, err error) {
 }
 
 // readMsg reads the next MIDI message that started with canary
+// unknown messages are skipped
 func (r *reader) readMsg(canary byte) (m midi.Message, err error) {
+	for {
+		m, err = r.readSingleMsg(canary)
+
+		if err != nil || m != nil {
+			return
+		}
+
+		// unknown event: read until next status byte
+		canary, err = r.discardUntilNextStatus()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readSingleMsg reads the MIDI message that started with canary
+// m is nil for unknown messages
+func (r *reader) readSingleMsg(canary byte) (m midi.Message, err error) {
+	// the canary is the last byte that has been read
+	r.msgOffset = r.counter.N - 1
 	status, changed := r.runningStatus.Read(canary)
 
+	r.status = status
+	if status == 0 {
+		r.status = canary
+	}
+
 	//	fmt.Printf("canary: % X, status: % X\n", canary, status)
 
 	// the cached running status has been reset, because a status byte
@@ -159,7 +219,7 @@ func (r *reader) readMsg(canary byte) (m midi.Message, err error) {
 
 		case 0xF7:
 			// we should never have a 0xF7 since sysex must already have consumed it
-			panic("unreachable")
+			err = errUnexpectedEndOfSysEx
 
 		default:
 			// must be a system common message, but no sysex (0xF0 < canary < 0xF7)
@@ -183,20 +243,5 @@ func (r *reader) readMsg(canary byte) (m midi.Message, err error) {
 		m, err = r.channelReader.Read(status, arg1)
 	}
 
-	if err != nil {
-		return
-	}
-
-	// unknown event: read until next status byte
-	if m == nil {
-
-		canary, err = r.discardUntilNextStatus()
-		if err != nil {
-			return
-		}
-		// return the next message
-		return r.readMsg(canary)
-	}
-
 	return
 }
@@ -2,9 +2,11 @@ package midireader
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"testing"
 
+	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/midimessage/channel"
 	"github.com/gomidi/midi/midimessage/realtime"
 	"github.com/gomidi/midi/midimessage/syscommon"
@@ -99,3 +101,88 @@ channel.NoteOff channel 2 key 62
 	}
 
 }
+
+type brokenReader struct {
+	data []byte
+}
+
+var errBroken = errors.New("broken")
+
+func (b *brokenReader) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		return 0, errBroken
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+func TestReadError(t *testing.T) {
+	// noteon, noteoff and a truncated pitchbend
+	rd := New(&brokenReader{[]byte{0x91, 0x41, 0x64, 0x81, 0x41, 0x40, 0xE2, 0x00}}, nil)
+
+	var err error
+
+	for err == nil {
+		_, err = rd.Read()
+	}
+
+	rerr, is := err.(*midi.ReadError)
+
+	if !is {
+		t.Fatalf("expected *midi.ReadError, got: %#v", err)
+	}
+
+	if got, want := rerr.Offset, int64(6); got != want {
+		t.Errorf("Offset = %v; want %v", got, want)
+	}
+
+	if got, want := rerr.Track, int16(-1); got != want {
+		t.Errorf("Track = %v; want %v", got, want)
+	}
+
+	if got, want := rerr.Status, byte(0xE2); got != want {
+		t.Errorf("Status = % X; want % X", got, want)
+	}
+
+	if got, want := rerr.Err, errBroken; got != want {
+		t.Errorf("Err = %v; want %v", got, want)
+	}
+}
+
+func TestReadEOF(t *testing.T) {
+	rd := New(bytes.NewReader([]byte{0x91, 0x41, 0x64}), nil)
+
+	_, err := rd.Read()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = rd.Read()
+
+	if err != io.EOF {
+		t.Errorf("expected io.EOF, got: %#v", err)
+	}
+}
+
+func TestReadUnexpectedEndOfSysEx(t *testing.T) {
+	rd := New(bytes.NewReader([]byte{0xF7, 0x91, 0x41, 0x64}), nil)
+
+	_, err := rd.Read()
+
+	if _, is := err.(*midi.ReadError); !is {
+		t.Fatalf("expected *midi.ReadError, got: %#v", err)
+	}
+
+	// reading may go on after the error
+	m, err := rd.Read()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := m.String(), "channel.NoteOn channel 1 key 65 velocity 100"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
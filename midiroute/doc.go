@@ -0,0 +1,36 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midiroute provides filters and transformations for live MIDI streams and a merger for multiple inputs.
+
+A Transform changes a single message or drops it. Transforms are chained and applied to the messages that
+are read from a midi.Reader, before they are written to a midi.Writer.
+
+Usage
+
+	import (
+		"github.com/gomidi/midi/midimessage/channel"
+		"github.com/gomidi/midi/midireader"
+		"github.com/gomidi/midi/midiroute"
+		"github.com/gomidi/midi/midiwriter"
+	)
+
+	// take channel 1 from the keyboard, send it to channel 10 and drop aftertouch
+	err := midiroute.Pipe(
+		midireader.New(keyboard, nil),
+		midiwriter.New(out),
+		midiroute.OnlyChannel(0),
+		midiroute.MapChannel(0, 9),
+		midiroute.DropTypes(channel.Aftertouch{}, channel.PolyAftertouch{}),
+	)
+
+	// merge two inputs
+	m := midiroute.NewMerger(midiwriter.New(out))
+	m.Add(midireader.New(in1, nil))
+	m.Add(midireader.New(in2, nil), midiroute.MapChannel(0, 1))
+	err = m.Wait()
+
+*/
+package midiroute
@@ -0,0 +1,141 @@
+package midiroute
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+// ErrSplitSysEx is returned by Merger.Write for the parts of a system exclusive message (sysex.Start and sysex.Continue),
+// since they would block the inputs until the end is written
+var ErrSplitSysEx = errors.New("split system exclusive messages can't be written directly")
+
+// Merger merges the messages of multiple inputs into a single midi.Writer.
+// The writer is only accessed by one input at a time, so that it may use running status (see midiwriter).
+// A system exclusive message that is split into parts (sysex.Start, sysex.Continue and sysex.End)
+// is not interrupted by messages of other inputs, except for realtime messages.
+type Merger struct {
+	output midi.Writer
+
+	mx   sync.Mutex
+	cond *sync.Cond
+
+	// sysexOwner is the input that has started a sysex that is not finished (0 if there is none)
+	sysexOwner int
+	lastInput  int
+
+	wg  sync.WaitGroup
+	err error
+}
+
+// NewMerger returns a Merger that writes to the given writer
+func NewMerger(wr midi.Writer) *Merger {
+	m := &Merger{output: wr}
+	m.cond = sync.NewCond(&m.mx)
+	return m
+}
+
+// Add reads the messages from the given reader in a separate goroutine, applies the transforms
+// and writes the remaining messages to the output, until reading or writing fails.
+func (m *Merger) Add(rd midi.Reader, transforms ...Transform) {
+	m.mx.Lock()
+	m.lastInput++
+	input := m.lastInput
+	m.mx.Unlock()
+
+	tr := Chain(transforms...)
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		defer m.release(input)
+
+		for {
+			msg, err := rd.Read()
+
+			if err != nil {
+				if err != io.EOF {
+					m.setError(err)
+				}
+				return
+			}
+
+			if msg = tr(msg); msg == nil {
+				continue
+			}
+
+			if err := m.write(input, msg); err != nil {
+				m.setError(err)
+				return
+			}
+		}
+	}()
+}
+
+// Write writes the given message to the output. It may be called concurrently with the inputs.
+// A system exclusive message must be written as a whole (see ErrSplitSysEx).
+func (m *Merger) Write(msg midi.Message) error {
+	switch msg.(type) {
+	case sysex.Start, sysex.Continue:
+		return ErrSplitSysEx
+	}
+
+	// Write is treated as an input of its own
+	return m.write(-1, msg)
+}
+
+// Wait waits until all inputs are finished and returns the first error of reading or writing.
+// The end of an input (io.EOF) is not an error.
+func (m *Merger) Wait() error {
+	m.wg.Wait()
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.err
+}
+
+func (m *Merger) setError(err error) {
+	m.mx.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.mx.Unlock()
+}
+
+func (m *Merger) write(input int, msg midi.Message) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	_, isRealtime := msg.(realtime.Message)
+
+	for !isRealtime && m.sysexOwner != 0 && m.sysexOwner != input {
+		m.cond.Wait()
+	}
+
+	err := m.output.Write(msg)
+
+	switch msg.(type) {
+	case sysex.Start, sysex.Continue:
+		m.sysexOwner = input
+	default:
+		if !isRealtime && m.sysexOwner == input {
+			m.sysexOwner = 0
+			m.cond.Broadcast()
+		}
+	}
+
+	return err
+}
+
+// release releases an unfinished sysex of the input
+func (m *Merger) release(input int) {
+	m.mx.Lock()
+	if m.sysexOwner == input {
+		m.sysexOwner = 0
+		m.cond.Broadcast()
+	}
+	m.mx.Unlock()
+}
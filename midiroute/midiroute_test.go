@@ -0,0 +1,228 @@
+package midiroute
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/midireader"
+	"github.com/gomidi/midi/midiwriter"
+)
+
+type sliceReader []midi.Message
+
+func (s *sliceReader) Read() (midi.Message, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	msg := (*s)[0]
+	*s = (*s)[1:]
+	return msg, nil
+}
+
+// chanReader reads the messages from a channel, until it is closed
+type chanReader chan midi.Message
+
+func (c chanReader) Read() (midi.Message, error) {
+	msg, ok := <-c
+	if !ok {
+		return nil, io.EOF
+	}
+	return msg, nil
+}
+
+// recorder records the written messages and signals each write
+type recorder struct {
+	mx      sync.Mutex
+	msgs    []string
+	written chan bool
+}
+
+func (r *recorder) Write(msg midi.Message) error {
+	r.mx.Lock()
+	r.msgs = append(r.msgs, msg.String())
+	r.mx.Unlock()
+	r.written <- true
+	return nil
+}
+
+func (r *recorder) String() string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return strings.Join(r.msgs, "\n")
+}
+
+func TestTransforms(t *testing.T) {
+	ch := channel.Channel0
+
+	tests := []struct {
+		descr     string
+		transform Transform
+		msg       midi.Message
+		expected  string
+	}{
+		{"only channel other", OnlyChannel(1), ch.NoteOn(60, 100), "<nil>"},
+		{"only channel same", OnlyChannel(0), ch.NoteOn(60, 100), "channel.NoteOn channel 0 key 60 velocity 100"},
+		{"only channel no channel message", OnlyChannel(1), realtime.Start, "Start"},
+		{"map channel", MapChannel(0, 9), ch.Pitchbend(200), "channel.Pitchbend channel 9 value 200 absValue 0"},
+		{"map channel other", MapChannel(1, 9), ch.NoteOff(60), "channel.NoteOff channel 0 key 60"},
+		{"channel map", ChannelMap(map[uint8]uint8{0: 3}), ch.PolyAftertouch(65, 20), "channel.PolyAftertouch channel 3 key 65 pressure 20"},
+		{"drop types", DropTypes(channel.Aftertouch{}, channel.PolyAftertouch{}), ch.PolyAftertouch(65, 20), "<nil>"},
+		{"drop types other", DropTypes(channel.Aftertouch{}), ch.NoteOff(60), "channel.NoteOff channel 0 key 60"},
+		{"only types", OnlyTypes(channel.NoteOn{}, channel.NoteOff{}), ch.ControlChange(7, 80), "<nil>"},
+		{"only types same", OnlyTypes(channel.NoteOn{}, channel.NoteOff{}), ch.NoteOff(60), "channel.NoteOff channel 0 key 60"},
+		{"split lower", Split(60, 1, 2), ch.NoteOn(59, 100), "channel.NoteOn channel 1 key 59 velocity 100"},
+		{"split upper", Split(60, 1, 2), ch.NoteOffVelocity(60, 30), "channel.NoteOffVelocity channel 2 key 60 velocity 30"},
+		{"split no note", Split(60, 1, 2), ch.ControlChange(7, 80), `channel.ControlChange channel 0 controller 7 ("Volume (MSB)") value 80`},
+		{"key range inside", KeyRange(60, 72), ch.NoteOn(72, 100), "channel.NoteOn channel 0 key 72 velocity 100"},
+		{"key range outside", KeyRange(60, 72), ch.NoteOn(73, 100), "<nil>"},
+		{"velocity", Velocity(func(v uint8) uint8 { return v / 2 }), ch.NoteOn(60, 100), "channel.NoteOn channel 0 key 60 velocity 50"},
+		{"velocity min", Velocity(func(v uint8) uint8 { return 0 }), ch.NoteOn(60, 100), "channel.NoteOn channel 0 key 60 velocity 1"},
+		{"velocity max", Velocity(func(v uint8) uint8 { return 200 }), ch.NoteOn(60, 100), "channel.NoteOn channel 0 key 60 velocity 127"},
+		{"controller map", ControllerMap(map[uint8]uint8{1: 11}), ch.ControlChange(1, 80), `channel.ControlChange channel 0 controller 11 ("Expression (MSB)") value 80`},
+		{"chain", Chain(OnlyChannel(0), MapChannel(0, 1), OnlyChannel(0)), ch.NoteOn(60, 100), "<nil>"},
+	}
+
+	for _, test := range tests {
+		var got = "<nil>"
+
+		if msg := test.transform(test.msg); msg != nil {
+			got = msg.String()
+		}
+
+		if got != test.expected {
+			t.Errorf("[%s] got: %s wanted: %s", test.descr, got, test.expected)
+		}
+	}
+}
+
+func TestPipe(t *testing.T) {
+	var in, out bytes.Buffer
+	ch := channel.Channel0
+
+	wr := midiwriter.New(&in)
+	wr.Write(ch.NoteOn(60, 100))
+	wr.Write(channel.Channel1.NoteOn(62, 100))
+	wr.Write(ch.NoteOff(60))
+
+	err := Pipe(midireader.New(&in, nil), midiwriter.New(&out), OnlyChannel(0), MapChannel(0, 2))
+
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	var got []string
+	rd := NewReader(midireader.New(&out, nil), Velocity(func(v uint8) uint8 { return v + 10 }))
+
+	for {
+		msg, err := rd.Read()
+		if err != nil {
+			break
+		}
+		got = append(got, msg.String())
+	}
+
+	expected := `channel.NoteOn channel 2 key 60 velocity 110
+channel.NoteOff channel 2 key 60`
+
+	if s := strings.Join(got, "\n"); s != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", s, expected)
+	}
+}
+
+func TestMergerSysExNotInterrupted(t *testing.T) {
+	rec := &recorder{written: make(chan bool, 10)}
+	m := NewMerger(rec)
+
+	a, b := make(chanReader), make(chanReader)
+	m.Add(a)
+	m.Add(b, MapChannel(0, 1))
+
+	a <- sysex.Start{0x41}
+	<-rec.written
+
+	// must wait for the end of the sysex
+	b <- channel.Channel0.NoteOn(60, 100)
+	time.Sleep(20 * time.Millisecond)
+
+	// realtime messages are not blocked
+	a <- realtime.TimingClock
+	<-rec.written
+
+	a <- sysex.Continue{0x10}
+	<-rec.written
+	a <- sysex.End{0x20}
+	<-rec.written
+	<-rec.written
+
+	close(a)
+	close(b)
+
+	if err := m.Wait(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	expected := fmt.Sprintf("%s\n%s\n%s\n%s\n%s",
+		sysex.Start{0x41},
+		realtime.TimingClock,
+		sysex.Continue{0x10},
+		sysex.End{0x20},
+		channel.Channel1.NoteOn(60, 100),
+	)
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestMergerAbandonedSysEx(t *testing.T) {
+	rec := &recorder{written: make(chan bool, 10)}
+	m := NewMerger(rec)
+
+	a := sliceReader{sysex.Start{0x41}}
+	m.Add(&a)
+
+	if err := m.Wait(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	// the sysex of the finished input does not block
+	if err := m.Write(channel.Channel0.NoteOff(60)); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	expected := fmt.Sprintf("%s\n%s", sysex.Start{0x41}, channel.Channel0.NoteOff(60))
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestMergerWriteSplitSysEx(t *testing.T) {
+	rec := &recorder{written: make(chan bool, 10)}
+	m := NewMerger(rec)
+
+	if err := m.Write(sysex.Start{0x41}); err != ErrSplitSysEx {
+		t.Errorf("expected ErrSplitSysEx, got %v", err)
+	}
+
+	// the inputs are not blocked
+	a := sliceReader{channel.Channel0.NoteOn(60, 100)}
+	m.Add(&a)
+
+	if err := m.Wait(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	if got, expected := rec.String(), channel.Channel0.NoteOn(60, 100).String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
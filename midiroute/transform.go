@@ -0,0 +1,233 @@
+package midiroute
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Transform transforms a MIDI message. It returns nil, if the message should be dropped.
+type Transform func(midi.Message) midi.Message
+
+// Chain returns a Transform that applies the given transforms in order, until a message is dropped
+func Chain(transforms ...Transform) Transform {
+	return func(msg midi.Message) midi.Message {
+		for _, tr := range transforms {
+			if msg = tr(msg); msg == nil {
+				return nil
+			}
+		}
+		return msg
+	}
+}
+
+// Pipe reads the messages from rd, applies the transforms and writes the remaining messages to wr.
+// It returns, when reading or writing fails. The end of the input (io.EOF) is not returned as error.
+func Pipe(rd midi.Reader, wr midi.Writer, transforms ...Transform) error {
+	tr := Chain(transforms...)
+
+	for {
+		msg, err := rd.Read()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if msg = tr(msg); msg == nil {
+			continue
+		}
+
+		if err := wr.Write(msg); err != nil {
+			return err
+		}
+	}
+}
+
+type reader struct {
+	input     midi.Reader
+	transform Transform
+}
+
+// NewReader returns a midi.Reader that reads from the given reader and applies the transforms.
+// Dropped messages are skipped.
+func NewReader(rd midi.Reader, transforms ...Transform) midi.Reader {
+	return &reader{input: rd, transform: Chain(transforms...)}
+}
+
+// Read reads the next message that is not dropped by the transforms
+func (r *reader) Read() (midi.Message, error) {
+	for {
+		msg, err := r.input.Read()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if msg = r.transform(msg); msg != nil {
+			return msg, nil
+		}
+	}
+}
+
+// Filter returns a Transform that drops the messages for which keep returns false
+func Filter(keep func(midi.Message) bool) Transform {
+	return func(msg midi.Message) midi.Message {
+		if keep(msg) {
+			return msg
+		}
+		return nil
+	}
+}
+
+func types(msgs []midi.Message) map[reflect.Type]bool {
+	ts := map[reflect.Type]bool{}
+	for _, m := range msgs {
+		ts[reflect.TypeOf(m)] = true
+	}
+	return ts
+}
+
+// DropTypes returns a Transform that drops the messages that have the same type as one of the given messages,
+// e.g. DropTypes(channel.Aftertouch{}, channel.PolyAftertouch{})
+func DropTypes(msgs ...midi.Message) Transform {
+	ts := types(msgs)
+	return Filter(func(msg midi.Message) bool {
+		return !ts[reflect.TypeOf(msg)]
+	})
+}
+
+// OnlyTypes returns a Transform that drops the messages that don't have the same type as one of the given messages
+func OnlyTypes(msgs ...midi.Message) Transform {
+	ts := types(msgs)
+	return Filter(func(msg midi.Message) bool {
+		return ts[reflect.TypeOf(msg)]
+	})
+}
+
+// OnlyChannel returns a Transform that drops the channel messages of other channels.
+// Other messages are not affected.
+func OnlyChannel(ch uint8) Transform {
+	return Filter(func(msg midi.Message) bool {
+		cm, is := msg.(channel.Message)
+		return !is || cm.Channel() == ch
+	})
+}
+
+// MapChannel returns a Transform that moves the channel messages of channel from to channel to
+func MapChannel(from, to uint8) Transform {
+	return ChannelMap(map[uint8]uint8{from: to})
+}
+
+// ChannelMap returns a Transform that moves the channel messages to the channels given by the map.
+// The channels that are not in the map are not changed. The target channels must be 0-15 (see channel.SetChannel).
+func ChannelMap(m map[uint8]uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		cm, is := msg.(channel.Message)
+
+		if !is {
+			return msg
+		}
+
+		if to, has := m[cm.Channel()]; has {
+			return channel.SetChannel(cm, to)
+		}
+
+		return msg
+	}
+}
+
+// noteKey returns the key of note and polyphonic aftertouch messages
+func noteKey(msg midi.Message) (key uint8, isNote bool) {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		return v.Key(), true
+	case channel.NoteOff:
+		return v.Key(), true
+	case channel.NoteOffVelocity:
+		return v.Key(), true
+	case channel.PolyAftertouch:
+		return v.Key(), true
+	}
+	return 0, false
+}
+
+// Split returns a Transform that moves the note and polyphonic aftertouch messages with keys below the given key
+// to the channel lower and the others to the channel upper (a keyboard split). The channels must be 0-15.
+func Split(key, lower, upper uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		k, isNote := noteKey(msg)
+
+		if !isNote {
+			return msg
+		}
+
+		if k < key {
+			return channel.SetChannel(msg.(channel.Message), lower)
+		}
+
+		return channel.SetChannel(msg.(channel.Message), upper)
+	}
+}
+
+// KeyRange returns a Transform that drops the note and polyphonic aftertouch messages with keys outside
+// of the range from low to high (inclusive)
+func KeyRange(low, high uint8) Transform {
+	return Filter(func(msg midi.Message) bool {
+		k, isNote := noteKey(msg)
+		return !isNote || (k >= low && k <= high)
+	})
+}
+
+// Velocity returns a Transform that changes the velocity of note on messages with the given curve.
+// The velocity of a note on message is kept in the range 1-127, so that it does not become a note off.
+func Velocity(curve func(velocity uint8) uint8) Transform {
+	return VelocityByKey(func(key, velocity uint8) uint8 {
+		return curve(velocity)
+	})
+}
+
+// VelocityByKey is like Velocity, but the curve also gets the key of the note on message
+func VelocityByKey(curve func(key, velocity uint8) uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		on, is := msg.(channel.NoteOn)
+
+		if !is || on.Velocity() == 0 {
+			return msg
+		}
+
+		vel := curve(on.Key(), on.Velocity())
+
+		switch {
+		case vel < 1:
+			vel = 1
+		case vel > 127:
+			vel = 127
+		}
+
+		return channel.Channel(on.Channel()).NoteOn(on.Key(), vel)
+	}
+}
+
+// ControllerMap returns a Transform that changes the controllers of control change messages as given by the map.
+// The controllers that are not in the map are not changed.
+func ControllerMap(m map[uint8]uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		cc, is := msg.(channel.ControlChange)
+
+		if !is {
+			return msg
+		}
+
+		if to, has := m[cc.Controller()]; has {
+			return channel.Channel(cc.Channel()).ControlChange(to, cc.Value())
+		}
+
+		return msg
+	}
+}
@@ -0,0 +1,173 @@
+package midischedule
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the time for a Scheduler and calls functions at given times
+type Clock interface {
+	// Now returns the time since the start of the clock
+	Now() time.Duration
+
+	// At calls f when the clock reaches the time t. The returned function cancels the call,
+	// if it has not been started yet.
+	At(t time.Duration, f func()) (cancel func())
+}
+
+type realClock struct {
+	start time.Time
+	spin  time.Duration
+}
+
+// NewClock returns a Clock that is based on the system clock and starts now.
+// Since timers of the operating system may wake up late, the clock sleeps until spin before
+// the target time and then spins (yielding the processor) until the target time is reached.
+// The greater spin, the more precise the timing and the more CPU is used.
+func NewClock(spin time.Duration) Clock {
+	return &realClock{start: time.Now(), spin: spin}
+}
+
+func (c *realClock) Now() time.Duration {
+	return time.Since(c.start)
+}
+
+func (c *realClock) At(t time.Duration, f func()) func() {
+	stop := make(chan struct{})
+
+	go func() {
+		if d := t - c.Now() - c.spin; d > 0 {
+			timer := time.NewTimer(d)
+
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+
+		for c.Now() < t {
+			select {
+			case <-stop:
+				return
+			default:
+				runtime.Gosched()
+			}
+		}
+
+		select {
+		case <-stop:
+		default:
+			f()
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(stop)
+		})
+	}
+}
+
+type virtualCall struct {
+	at        time.Duration
+	seq       uint64
+	f         func()
+	cancelled bool
+}
+
+// VirtualClock is a Clock that only advances when Advance is called. It makes the timing deterministic for tests.
+type VirtualClock struct {
+	mx      sync.Mutex
+	now     time.Duration
+	seq     uint64
+	pending []*virtualCall
+}
+
+// NewVirtualClock returns a VirtualClock at time 0
+func NewVirtualClock() *VirtualClock {
+	return &VirtualClock{}
+}
+
+// Now returns the current time of the clock
+func (v *VirtualClock) Now() time.Duration {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	return v.now
+}
+
+// At registers f to be called by Advance, when the clock reaches the time t.
+// If t is not in the future, f is called by the next call of Advance.
+func (v *VirtualClock) At(t time.Duration, f func()) func() {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+
+	v.seq++
+	c := &virtualCall{at: t, seq: v.seq, f: f}
+	v.pending = append(v.pending, c)
+
+	return func() {
+		v.mx.Lock()
+		c.cancelled = true
+		v.mx.Unlock()
+	}
+}
+
+// Advance advances the clock by d and calls the registered functions that are due in the order of their times
+// (functions with the same time in the order of registration). Each function is called with the clock set to its time.
+// Functions that are registered by the called functions are also called, if they are due.
+// Advance returns after all due functions have returned.
+func (v *VirtualClock) Advance(d time.Duration) {
+	v.mx.Lock()
+	end := v.now + d
+
+	for {
+		c := v.nextDue(end)
+
+		if c == nil {
+			break
+		}
+
+		if c.at > v.now {
+			v.now = c.at
+		}
+
+		v.mx.Unlock()
+		c.f()
+		v.mx.Lock()
+	}
+
+	v.now = end
+	v.mx.Unlock()
+}
+
+// nextDue removes and returns the next call that is due at the time end (nil if there is none)
+func (v *VirtualClock) nextDue(end time.Duration) *virtualCall {
+	var calls []*virtualCall
+
+	for _, c := range v.pending {
+		if !c.cancelled {
+			calls = append(calls, c)
+		}
+	}
+
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].at == calls[j].at {
+			return calls[i].seq < calls[j].seq
+		}
+		return calls[i].at < calls[j].at
+	})
+
+	if len(calls) == 0 || calls[0].at > end {
+		v.pending = calls
+		return nil
+	}
+
+	v.pending = calls[1:]
+	return calls[0]
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midischedule provides a Scheduler that writes MIDI messages to a midi.Writer at given times.
+
+The times are durations since the start of the Clock of the Scheduler. The default Clock is based on the
+system clock and combines sleeping with spinning for a precise timing. A VirtualClock only advances when
+told to, so that tests are deterministic.
+
+The Scheduler can also call functions at given times (e.g. for players or delays) and is a midigen.Scheduler.
+Stop cancels everything that is pending and ends the notes that are still sounding.
+
+Usage
+
+	import (
+		"time"
+
+		"github.com/gomidi/midi/midimessage/channel"
+		"github.com/gomidi/midi/midischedule"
+		"github.com/gomidi/midi/midiwriter"
+	)
+
+	s := midischedule.New(midiwriter.New(out))
+
+	now := s.Now()
+	s.Schedule(now, channel.Channel0.NoteOn(60, 100))
+	id := s.Schedule(now+500*time.Millisecond, channel.Channel0.NoteOff(60))
+
+	// changed our mind
+	s.Cancel(id)
+
+	// ends the note
+	err := s.Stop()
+
+For tests
+
+	clock := midischedule.NewVirtualClock()
+	s := midischedule.New(wr, midischedule.WithClock(clock))
+	s.Schedule(10*time.Millisecond, channel.Channel0.NoteOn(60, 100))
+
+	// writes the note on message
+	clock.Advance(10 * time.Millisecond)
+
+*/
+package midischedule
@@ -0,0 +1,272 @@
+package midischedule
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midigen"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+var _ midigen.Scheduler = &Scheduler{}
+
+// recorder records the written messages with the time of the clock
+type recorder struct {
+	mx    sync.Mutex
+	clock Clock
+	lines []string
+}
+
+func (r *recorder) Write(msg midi.Message) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.lines = append(r.lines, fmt.Sprintf("%v %s", r.clock.Now(), msg))
+	return nil
+}
+
+func (r *recorder) String() string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return strings.Join(r.lines, "\n")
+}
+
+func TestScheduleAndCancel(t *testing.T) {
+	clock := NewVirtualClock()
+	rec := &recorder{clock: clock}
+	s := New(rec, WithClock(clock))
+	ch := channel.Channel0
+
+	s.Schedule(20*time.Millisecond, ch.NoteOff(60))
+	s.Schedule(10*time.Millisecond, ch.NoteOn(60, 100), ch.NoteOn(64, 100))
+	s.Schedule(10*time.Millisecond, ch.NoteOn(67, 100))
+	id := s.Schedule(30*time.Millisecond, ch.NoteOff(64))
+
+	clock.Advance(15 * time.Millisecond)
+
+	if !s.Cancel(id) {
+		t.Errorf("could not cancel pending event")
+	}
+
+	if s.Cancel(id) {
+		t.Errorf("cancelled event twice")
+	}
+
+	// in the past
+	s.Schedule(5*time.Millisecond, ch.NoteOff(67))
+
+	clock.Advance(100 * time.Millisecond)
+
+	expected := `10ms channel.NoteOn channel 0 key 60 velocity 100
+10ms channel.NoteOn channel 0 key 64 velocity 100
+10ms channel.NoteOn channel 0 key 67 velocity 100
+15ms channel.NoteOff channel 0 key 67
+20ms channel.NoteOff channel 0 key 60`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	if err := s.Err(); err != nil {
+		t.Errorf("error: %v", err)
+	}
+}
+
+func TestScheduleFunc(t *testing.T) {
+	clock := NewVirtualClock()
+	rec := &recorder{clock: clock}
+	s := New(rec, WithClock(clock))
+
+	// a metronome that schedules itself
+	var beat func()
+	beat = func() {
+		s.Write(channel.Channel9.NoteOn(37, 100))
+		s.Schedule(s.Now()+10*time.Millisecond, channel.Channel9.NoteOff(37))
+		s.AfterFunc(500*time.Millisecond, beat)
+	}
+
+	s.ScheduleFunc(0, beat)
+	clock.Advance(time.Second)
+
+	expected := `0s channel.NoteOn channel 9 key 37 velocity 100
+10ms channel.NoteOff channel 9 key 37
+500ms channel.NoteOn channel 9 key 37 velocity 100
+510ms channel.NoteOff channel 9 key 37
+1s channel.NoteOn channel 9 key 37 velocity 100`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestStop(t *testing.T) {
+	clock := NewVirtualClock()
+	rec := &recorder{clock: clock}
+	s := New(rec, WithClock(clock))
+
+	s.Schedule(0, channel.Channel1.NoteOn(62, 100), channel.Channel0.NoteOn(60, 100), channel.Channel0.NoteOn(64, 100))
+	s.Schedule(5*time.Millisecond, channel.Channel0.NoteOff(64))
+	s.Schedule(50*time.Millisecond, channel.Channel0.NoteOff(60))
+
+	clock.Advance(10 * time.Millisecond)
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	clock.Advance(100 * time.Millisecond)
+
+	expected := `0s channel.NoteOn channel 1 key 62 velocity 100
+0s channel.NoteOn channel 0 key 60 velocity 100
+0s channel.NoteOn channel 0 key 64 velocity 100
+5ms channel.NoteOff channel 0 key 64
+10ms channel.NoteOff channel 0 key 60
+10ms channel.NoteOff channel 1 key 62
+10ms channel.ControlChange channel 0 controller 123 ("All Notes Off") value 0
+10ms channel.ControlChange channel 1 controller 123 ("All Notes Off") value 0`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestArpeggiator(t *testing.T) {
+	clock := NewVirtualClock()
+	rec := &recorder{clock: clock}
+	s := New(rec, WithClock(clock))
+
+	// eighth notes at 120 BPM: 250ms
+	arp := midigen.NewArpeggiator(s, s, midigen.Rate(8), midigen.Gate(100))
+
+	arp.Write(channel.Channel0.NoteOn(60, 100))
+	arp.Write(channel.Channel0.NoteOn(72, 100))
+	clock.Advance(600 * time.Millisecond)
+	s.Stop()
+
+	expected := `0s channel.NoteOn channel 0 key 60 velocity 100
+250ms channel.NoteOff channel 0 key 60
+250ms channel.NoteOn channel 0 key 72 velocity 100
+500ms channel.NoteOff channel 0 key 72
+500ms channel.NoteOn channel 0 key 60 velocity 100
+600ms channel.NoteOff channel 0 key 60
+600ms channel.ControlChange channel 0 controller 123 ("All Notes Off") value 0`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestOutputCallsScheduler(t *testing.T) {
+	clock := NewVirtualClock()
+	rec := &recorder{clock: clock}
+
+	// the arpeggiator is the output of the scheduler and uses the scheduler
+	var arp *midigen.Arpeggiator
+
+	s := New(writerFunc(func(msg midi.Message) error {
+		return arp.Write(msg)
+	}), WithClock(clock))
+
+	arp = midigen.NewArpeggiator(rec, s)
+
+	s.Schedule(0, channel.Channel0.NoteOn(60, 100))
+	s.Schedule(300*time.Millisecond, channel.Channel0.NoteOff(60))
+	clock.Advance(time.Second)
+
+	expected := `0s channel.NoteOn channel 0 key 60 velocity 100
+62.5ms channel.NoteOff channel 0 key 60
+125ms channel.NoteOn channel 0 key 60 velocity 100
+187.5ms channel.NoteOff channel 0 key 60
+250ms channel.NoteOn channel 0 key 60 velocity 100
+312.5ms channel.NoteOff channel 0 key 60`
+
+	if got := rec.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestStopWaitsForFunc(t *testing.T) {
+	clock := NewClock(time.Millisecond)
+	rec := &recorder{clock: clock}
+	s := New(rec, WithClock(clock))
+
+	started, release, stopped := make(chan bool), make(chan bool), make(chan error)
+
+	// e.g. an arpeggiator step
+	s.ScheduleFunc(s.Now(), func() {
+		started <- true
+		<-release
+		s.Write(channel.Channel0.NoteOn(60, 100))
+	})
+
+	<-started
+
+	go func() {
+		stopped <- s.Stop()
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatalf("Stop did not wait for the running function")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release <- true
+
+	if err := <-stopped; err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	var got []string
+
+	for _, line := range rec.lines {
+		got = append(got, line[strings.Index(line, " ")+1:])
+	}
+
+	expected := `channel.NoteOn channel 0 key 60 velocity 100
+channel.NoteOff channel 0 key 60
+channel.ControlChange channel 0 controller 123 ("All Notes Off") value 0`
+
+	if s := strings.Join(got, "\n"); s != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", s, expected)
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	clock := NewClock(time.Millisecond)
+	written := make(chan time.Duration, 1)
+
+	s := New(writerFunc(func(midi.Message) error {
+		written <- clock.Now()
+		return nil
+	}), WithClock(clock))
+
+	at := s.Now() + 5*time.Millisecond
+	s.Schedule(at, channel.Channel0.NoteOn(60, 100))
+	id := s.Schedule(at+time.Millisecond, channel.Channel0.NoteOff(60))
+	s.Cancel(id)
+
+	select {
+	case got := <-written:
+		if got < at {
+			t.Errorf("written at %v, before %v", got, at)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("message not written")
+	}
+
+	select {
+	case <-written:
+		t.Errorf("cancelled message written")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+type writerFunc func(midi.Message) error
+
+func (w writerFunc) Write(msg midi.Message) error {
+	return w(msg)
+}
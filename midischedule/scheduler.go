@@ -0,0 +1,295 @@
+package midischedule
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// ID identifies a scheduled event
+type ID uint64
+
+// Option is an option for the Scheduler
+type Option func(*Scheduler)
+
+// WithClock sets the clock of the scheduler (default: NewClock(time.Millisecond))
+func WithClock(c Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+type event struct {
+	id   ID
+	at   time.Duration
+	msgs []midi.Message
+	f    func()
+}
+
+// Scheduler writes messages to a midi.Writer at given times of its clock.
+// Messages with the same time are written in the order they were scheduled.
+// The scheduler keeps track of the notes that it has written, to end them on Stop.
+// All methods may be called concurrently. The output may call the scheduler, except for Write and Stop.
+type Scheduler struct {
+	// dispatching serializes the dispatching of due events and Stop
+	dispatching sync.Mutex
+
+	// writing serializes the writing to the output
+	writing sync.Mutex
+
+	// mx protects the following fields; it is not held while the output is written to
+	mx     sync.Mutex
+	output midi.Writer
+	clock  Clock
+
+	// queue are the pending events, ordered by time and id
+	queue   []*event
+	lastID  ID
+	armedAt time.Duration
+	armID   uint64
+	disarm  func()
+
+	sounding map[[2]uint8]bool
+	channels [16]bool
+	err      error
+}
+
+// New returns a Scheduler that writes to wr
+func New(wr midi.Writer, opts ...Option) *Scheduler {
+	s := &Scheduler{output: wr, sounding: map[[2]uint8]bool{}}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.clock == nil {
+		s.clock = NewClock(time.Millisecond)
+	}
+
+	return s
+}
+
+// Now returns the current time of the clock
+func (s *Scheduler) Now() time.Duration {
+	return s.clock.Now()
+}
+
+// Schedule schedules the messages to be written at the time at.
+// Messages with a time that has passed are written as soon as possible.
+func (s *Scheduler) Schedule(at time.Duration, msgs ...midi.Message) ID {
+	return s.add(&event{at: at, msgs: msgs})
+}
+
+// ScheduleFunc schedules f to be called at the time at. f may schedule further events.
+func (s *Scheduler) ScheduleFunc(at time.Duration, f func()) ID {
+	return s.add(&event{at: at, f: f})
+}
+
+// AfterFunc calls f after the duration d. The returned function cancels the call.
+func (s *Scheduler) AfterFunc(d time.Duration, f func()) (cancel func()) {
+	id := s.ScheduleFunc(s.Now()+d, f)
+	return func() {
+		s.Cancel(id)
+	}
+}
+
+// Cancel cancels the event with the given id. It returns false, if the event has already been dispatched or cancelled.
+func (s *Scheduler) Cancel(id ID) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for i, ev := range s.queue {
+		if ev.id == id {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			s.arm()
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write writes the message immediately
+func (s *Scheduler) Write(msg midi.Message) error {
+	return s.write(msg)
+}
+
+// Stop cancels all pending events, ends the sounding notes with note off messages and writes an
+// all notes off message (controller 123) to each channel that has been used for notes since the last Stop.
+// If a scheduled function is running, Stop waits until it has returned, so Stop must not be called by a
+// scheduled function. The scheduler can be used again afterwards.
+func (s *Scheduler) Stop() error {
+	s.dispatching.Lock()
+	defer s.dispatching.Unlock()
+
+	s.mx.Lock()
+	s.queue = nil
+	s.arm()
+
+	var notes [][2]uint8
+
+	for n := range s.sounding {
+		notes = append(notes, n)
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		if notes[i][0] == notes[j][0] {
+			return notes[i][1] < notes[j][1]
+		}
+		return notes[i][0] < notes[j][0]
+	})
+
+	channels := s.channels
+	s.channels = [16]bool{}
+	s.mx.Unlock()
+
+	for _, n := range notes {
+		if err := s.write(channel.Channel(n[0]).NoteOff(n[1])); err != nil {
+			return err
+		}
+	}
+
+	for ch, used := range channels {
+		if !used {
+			continue
+		}
+
+		if err := s.write(channel.Channel(ch).ControlChange(123, 0)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Err returns the first error of writing a scheduled message
+func (s *Scheduler) Err() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.err
+}
+
+func (s *Scheduler) add(ev *event) ID {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.lastID++
+	ev.id = s.lastID
+
+	// insert after the events with the same time
+	i := sort.Search(len(s.queue), func(i int) bool {
+		return s.queue[i].at > ev.at
+	})
+
+	s.queue = append(s.queue, nil)
+	copy(s.queue[i+1:], s.queue[i:])
+	s.queue[i] = ev
+
+	s.arm()
+	return ev.id
+}
+
+// arm sets the timer of the clock to the time of the first event
+func (s *Scheduler) arm() {
+	if s.disarm != nil {
+		if len(s.queue) > 0 && s.queue[0].at == s.armedAt {
+			return
+		}
+		s.disarm()
+		s.disarm = nil
+	}
+
+	if len(s.queue) == 0 {
+		return
+	}
+
+	s.armID++
+	id := s.armID
+	s.armedAt = s.queue[0].at
+	s.disarm = s.clock.At(s.armedAt, func() {
+		s.dispatch(id)
+	})
+}
+
+// dispatch writes the due messages and calls the due functions.
+// armID identifies the timer that has fired.
+func (s *Scheduler) dispatch(armID uint64) {
+	s.dispatching.Lock()
+	defer s.dispatching.Unlock()
+
+	s.mx.Lock()
+	if armID == s.armID {
+		s.disarm = nil
+	}
+	s.mx.Unlock()
+
+	for {
+		ev := s.nextDue()
+
+		if ev == nil {
+			break
+		}
+
+		if ev.f != nil {
+			ev.f()
+			continue
+		}
+
+		for _, msg := range ev.msgs {
+			if err := s.write(msg); err != nil {
+				s.mx.Lock()
+				if s.err == nil {
+					s.err = err
+				}
+				s.mx.Unlock()
+			}
+		}
+	}
+
+	s.mx.Lock()
+	s.arm()
+	s.mx.Unlock()
+}
+
+// nextDue removes and returns the next event that is due (nil if there is none)
+func (s *Scheduler) nextDue() *event {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if len(s.queue) == 0 || s.queue[0].at > s.clock.Now() {
+		return nil
+	}
+
+	ev := s.queue[0]
+	s.queue = s.queue[1:]
+	return ev
+}
+
+// write tracks the sounding notes and writes the message to the output
+func (s *Scheduler) write(msg midi.Message) error {
+	s.writing.Lock()
+	defer s.writing.Unlock()
+
+	s.mx.Lock()
+
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if v.Velocity() > 0 {
+			s.sounding[[2]uint8{v.Channel(), v.Key()}] = true
+			s.channels[v.Channel()] = true
+		} else {
+			delete(s.sounding, [2]uint8{v.Channel(), v.Key()})
+		}
+	case channel.NoteOff:
+		delete(s.sounding, [2]uint8{v.Channel(), v.Key()})
+	case channel.NoteOffVelocity:
+		delete(s.sounding, [2]uint8{v.Channel(), v.Key()})
+	}
+
+	s.mx.Unlock()
+
+	return s.output.Write(msg)
+}
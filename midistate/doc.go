@@ -0,0 +1,32 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midistate tracks the state of the 16 MIDI channels and reproduces it.
+
+A Tracker consumes channel messages (program changes, bank select and the other controllers,
+registered and non registered parameters, pitch bend, aftertouch and notes, including the sustain pedal).
+At any point it returns the messages that bring a receiver into the same state.
+That is needed, when the playback starts in the middle of a song or a device has to be resynced.
+
+Usage
+
+	import (
+		"github.com/gomidi/midi/midistate"
+		"github.com/gomidi/midi/midiwriter"
+	)
+
+	tr := midistate.New()
+
+	// pass all messages that are sent to the device through the tracker
+	for _, msg := range msgs {
+		tr.Track(msg)
+	}
+
+	// resync a device that has been (re)connected
+	wr := midiwriter.New(out)
+	err := tr.Write(wr)
+
+*/
+package midistate
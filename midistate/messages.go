@@ -0,0 +1,164 @@
+package midistate
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Messages returns the messages that reproduce the known state of all channels, channel by channel.
+// See ChannelMessages for the messages of a single channel.
+func (t *Tracker) Messages() (msgs []midi.Message) {
+	for ch := range t.channels {
+		msgs = append(msgs, t.ChannelMessages(uint8(ch))...)
+	}
+	return
+}
+
+// ChannelMessages returns the messages that reproduce the known state of the given channel.
+// Unknown values are left out and every value is set only once. The messages come in this order:
+// the mode messages, bank select and program change, the other controllers (including the sustain pedal),
+// the registered and non registered parameters (followed by the selection of the last selected parameter),
+// pitch bend, aftertouch, the held notes and the polyphonic aftertouch.
+// Notes that are kept by the sustain pedal are reproduced by a note on and a note off message.
+func (t *Tracker) ChannelMessages(ch uint8) (msgs []midi.Message) {
+	s := &t.channels[ch&0x0F]
+	c := channel.Channel(ch & 0x0F)
+
+	cc := func(controllers ...uint8) {
+		for _, controller := range controllers {
+			if val := s.controllers[controller]; val != unset {
+				msgs = append(msgs, c.ControlChange(controller, uint8(val)))
+			}
+		}
+	}
+
+	// the mode messages imply all notes off, so they must come first
+	cc(ccLocalControl, ccOmniOff, ccOmniOn, ccMonoOn, ccPolyOn)
+
+	// bank select comes before the program change
+	cc(ccBankMSB, ccBankLSB)
+
+	if s.program != unset {
+		msgs = append(msgs, c.ProgramChange(uint8(s.program)))
+	}
+
+	for controller := range s.controllers {
+		switch controller {
+		case ccBankMSB, ccBankLSB, ccLocalControl, ccOmniOff, ccOmniOn, ccMonoOn, ccPolyOn:
+		default:
+			cc(uint8(controller))
+		}
+	}
+
+	msgs = append(msgs, s.parameterMessages(c)...)
+
+	if s.pitchbend != pitchbendUnset {
+		msgs = append(msgs, c.Pitchbend(s.pitchbend))
+	}
+
+	if s.aftertouch != unset {
+		msgs = append(msgs, c.Aftertouch(uint8(s.aftertouch)))
+	}
+
+	for key := range s.notes {
+		for _, vel := range s.sustained[key] {
+			msgs = append(msgs, c.NoteOn(uint8(key), vel), c.NoteOff(uint8(key)))
+		}
+
+		for _, vel := range s.notes[key] {
+			msgs = append(msgs, c.NoteOn(uint8(key), vel))
+		}
+	}
+
+	// the pressure refers to the held notes
+	for key, pressure := range s.polyAftertouch {
+		if pressure != unset {
+			msgs = append(msgs, c.PolyAftertouch(uint8(key), uint8(pressure)))
+		}
+	}
+
+	return
+}
+
+// parameterMessages returns the messages for the values of the parameters and the parameter selection
+func (s *state) parameterMessages(c channel.Channel) (msgs []midi.Message) {
+	var last *parameter
+
+	sel := func(p parameter) {
+		if p.nrpn {
+			msgs = append(msgs, c.ControlChange(ccNRPNMSB, uint8(p.msb)), c.ControlChange(ccNRPNLSB, uint8(p.lsb)))
+		} else {
+			msgs = append(msgs, c.ControlChange(ccRPNMSB, uint8(p.msb)), c.ControlChange(ccRPNLSB, uint8(p.lsb)))
+		}
+	}
+
+	for i := range s.parameters {
+		v := &s.parameters[i]
+
+		if v.dataMSB == unset && v.dataLSB == unset {
+			continue
+		}
+
+		sel(v.parameter)
+		last = &v.parameter
+
+		if v.dataMSB != unset {
+			msgs = append(msgs, c.ControlChange(ccDataEntryMSB, uint8(v.dataMSB)))
+		}
+
+		if v.dataLSB != unset {
+			msgs = append(msgs, c.ControlChange(ccDataEntryLSB, uint8(v.dataLSB)))
+		}
+	}
+
+	current := s.rpn
+
+	if s.nrpnSelected {
+		current = s.nrpn
+	}
+
+	switch {
+	case current.msb != unset && current.lsb != unset:
+		if last == nil || *last != current {
+			sel(current)
+		}
+	case last != nil:
+		// protect the parameters against further data entry
+		sel(parameter{msb: parameterNull, lsb: parameterNull})
+	}
+
+	return
+}
+
+// NoteOffs returns the messages that end the held notes of all channels:
+// a note off message for each held note and a release of the sustain pedal, if it is down.
+// The state is not changed.
+func (t *Tracker) NoteOffs() (msgs []midi.Message) {
+	for i := range t.channels {
+		s := &t.channels[i]
+		c := channel.Channel(i)
+
+		for key, vels := range s.notes {
+			for range vels {
+				msgs = append(msgs, c.NoteOff(uint8(key)))
+			}
+		}
+
+		if s.sustain() {
+			msgs = append(msgs, c.ControlChange(ccSustain, 0))
+		}
+	}
+
+	return
+}
+
+// Write writes the messages that reproduce the known state of all channels to the given writer,
+// e.g. to resync a device. It stops at the first error.
+func (t *Tracker) Write(wr midi.Writer) error {
+	for _, msg := range t.Messages() {
+		if err := wr.Write(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package midistate
+
+import (
+	"math"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// controllers with a special meaning
+const (
+	ccBankMSB        = 0
+	ccDataEntryMSB   = 6
+	ccBankLSB        = 32
+	ccDataEntryLSB   = 38
+	ccSustain        = 64
+	ccDataIncrement  = 96
+	ccDataDecrement  = 97
+	ccNRPNLSB        = 98
+	ccNRPNMSB        = 99
+	ccRPNLSB         = 100
+	ccRPNMSB         = 101
+	ccAllSoundOff    = 120
+	ccResetAll       = 121
+	ccLocalControl   = 122
+	ccAllNotesOff    = 123
+	ccOmniOff        = 124
+	ccOmniOn         = 125
+	ccMonoOn         = 126
+	ccPolyOn         = 127
+	unset            = -1
+	pitchbendUnset   = math.MinInt16
+	parameterNull    = 127
+	sustainThreshold = 64
+)
+
+// Tracker tracks the state of the 16 MIDI channels.
+// The zero value is not usable, use New.
+type Tracker struct {
+	channels [16]state
+}
+
+// New returns a Tracker with an unknown state for all channels
+func New() *Tracker {
+	t := &Tracker{}
+	t.Reset()
+	return t
+}
+
+// parameter is a registered (RPN) or non registered (NRPN) parameter
+type parameter struct {
+	nrpn     bool
+	msb, lsb int16
+}
+
+// parameterValue is the data entry value of a parameter
+type parameterValue struct {
+	parameter
+	dataMSB, dataLSB int16
+}
+
+// state is the state of a single channel
+type state struct {
+	program        int16
+	controllers    [128]int16
+	pitchbend      int16
+	aftertouch     int16
+	polyAftertouch [128]int16
+
+	// selected parameter registers of the RPN and NRPN controllers
+	rpn, nrpn parameter
+
+	// nrpnSelected reports, whether the NRPN registers have been written last
+	nrpnSelected bool
+
+	// values of the parameters in the order they have been set first
+	parameters []parameterValue
+
+	// velocities of the notes that are held, one for each note on
+	notes [128][]uint8
+
+	// velocities of the notes that have been released while the sustain pedal was down
+	sustained [128][]uint8
+}
+
+func (s *state) reset() {
+	*s = state{
+		program:    unset,
+		pitchbend:  pitchbendUnset,
+		aftertouch: unset,
+		rpn:        parameter{msb: unset, lsb: unset},
+		nrpn:       parameter{nrpn: true, msb: unset, lsb: unset},
+	}
+
+	for i := range s.controllers {
+		s.controllers[i] = unset
+		s.polyAftertouch[i] = unset
+	}
+}
+
+// Reset sets the state of all channels to unknown
+func (t *Tracker) Reset() {
+	for i := range t.channels {
+		t.channels[i].reset()
+	}
+}
+
+// Copy returns a snapshot of the current state that is not affected by tracking further messages
+func (t *Tracker) Copy() *Tracker {
+	c := &Tracker{}
+	c.channels = t.channels
+
+	for i := range c.channels {
+		s := &c.channels[i]
+		s.parameters = append([]parameterValue(nil), s.parameters...)
+
+		for key := range s.notes {
+			s.notes[key] = append([]uint8(nil), s.notes[key]...)
+			s.sustained[key] = append([]uint8(nil), s.sustained[key]...)
+		}
+	}
+
+	return c
+}
+
+// Track updates the state with the given message. Messages that are not channel messages are ignored.
+// A note on message with velocity 0 is handled as note off message.
+func (t *Tracker) Track(msg midi.Message) {
+	switch m := msg.(type) {
+	case channel.NoteOn:
+		if m.Velocity() > 0 {
+			s := &t.channels[m.Channel()&0x0F]
+			s.notes[m.Key()] = append(s.notes[m.Key()], m.Velocity())
+			return
+		}
+		t.channels[m.Channel()&0x0F].noteOff(m.Key())
+	case channel.NoteOff:
+		t.channels[m.Channel()&0x0F].noteOff(m.Key())
+	case channel.NoteOffVelocity:
+		t.channels[m.Channel()&0x0F].noteOff(m.Key())
+	case channel.PolyAftertouch:
+		t.channels[m.Channel()&0x0F].polyAftertouch[m.Key()] = int16(m.Pressure())
+	case channel.ControlChange:
+		t.channels[m.Channel()&0x0F].controlChange(m.Controller()&0x7F, m.Value())
+	case channel.ProgramChange:
+		t.channels[m.Channel()&0x0F].program = int16(m.Program())
+	case channel.Aftertouch:
+		t.channels[m.Channel()&0x0F].aftertouch = int16(m.Pressure())
+	case channel.Pitchbend:
+		t.channels[m.Channel()&0x0F].pitchbend = m.Value()
+	}
+}
+
+func (s *state) sustain() bool {
+	return s.controllers[ccSustain] >= sustainThreshold
+}
+
+func (s *state) noteOff(key uint8) {
+	n := len(s.notes[key])
+
+	if n == 0 {
+		return
+	}
+
+	if s.sustain() {
+		s.sustained[key] = append(s.sustained[key], s.notes[key][0])
+	}
+
+	s.notes[key] = s.notes[key][1:]
+}
+
+// allNotesOff releases all held notes, the sustain pedal is respected
+func (s *state) allNotesOff() {
+	for key := range s.notes {
+		for len(s.notes[key]) > 0 {
+			s.noteOff(uint8(key))
+		}
+	}
+}
+
+func (s *state) controlChange(cc, val uint8) {
+	switch cc {
+	case ccRPNMSB:
+		s.rpn.msb = int16(val)
+		s.nrpnSelected = false
+	case ccRPNLSB:
+		s.rpn.lsb = int16(val)
+		s.nrpnSelected = false
+	case ccNRPNMSB:
+		s.nrpn.msb = int16(val)
+		s.nrpnSelected = true
+	case ccNRPNLSB:
+		s.nrpn.lsb = int16(val)
+		s.nrpnSelected = true
+	case ccDataEntryMSB:
+		if v := s.selectedValue(); v != nil {
+			v.dataMSB = int16(val)
+		}
+	case ccDataEntryLSB:
+		if v := s.selectedValue(); v != nil {
+			v.dataLSB = int16(val)
+		}
+	case ccDataIncrement, ccDataDecrement:
+		v := s.selectedValue()
+
+		if v == nil || v.dataMSB == unset {
+			return
+		}
+
+		lsb := v.dataLSB
+
+		if lsb == unset {
+			lsb = 0
+		}
+
+		value := v.dataMSB<<7 | lsb
+
+		if cc == ccDataIncrement && value < 0x3FFF {
+			value++
+		}
+
+		if cc == ccDataDecrement && value > 0 {
+			value--
+		}
+
+		v.dataMSB, v.dataLSB = value>>7, value&0x7F
+	case ccAllSoundOff:
+		for key := range s.notes {
+			s.notes[key] = nil
+			s.sustained[key] = nil
+		}
+	case ccResetAll:
+		s.resetControllers()
+	case ccAllNotesOff:
+		s.allNotesOff()
+	case ccOmniOff, ccOmniOn, ccMonoOn, ccPolyOn:
+		// the mode messages are exclusive in pairs and imply all notes off
+		switch cc {
+		case ccOmniOff, ccOmniOn:
+			s.controllers[ccOmniOff], s.controllers[ccOmniOn] = unset, unset
+		default:
+			s.controllers[ccMonoOn], s.controllers[ccPolyOn] = unset, unset
+		}
+		s.controllers[cc] = int16(val)
+		s.allNotesOff()
+	case ccSustain:
+		s.controllers[cc] = int16(val)
+
+		if val < sustainThreshold {
+			for key := range s.sustained {
+				s.sustained[key] = nil
+			}
+		}
+	default:
+		s.controllers[cc] = int16(val)
+	}
+}
+
+// resetControllers sets the values that are reset by the "Reset All Controllers" message (see MMA RP-015)
+func (s *state) resetControllers() {
+	s.controllers[1] = 0
+	s.controllers[11] = 127
+
+	for cc := ccSustain; cc <= 67; cc++ {
+		s.controllers[cc] = 0
+	}
+
+	for key := range s.sustained {
+		s.sustained[key] = nil
+	}
+
+	s.pitchbend = 0
+	s.aftertouch = 0
+
+	// a pressure of 0 is the same as no pressure
+	for key := range s.polyAftertouch {
+		s.polyAftertouch[key] = unset
+	}
+
+	s.rpn.msb, s.rpn.lsb = parameterNull, parameterNull
+	s.nrpn.msb, s.nrpn.lsb = parameterNull, parameterNull
+}
+
+// selectedValue returns the value of the selected parameter or nil, if no parameter is selected
+func (s *state) selectedValue() *parameterValue {
+	p := s.rpn
+
+	if s.nrpnSelected {
+		p = s.nrpn
+	}
+
+	if p.msb == unset || p.lsb == unset || (p.msb == parameterNull && p.lsb == parameterNull) {
+		return nil
+	}
+
+	for i := range s.parameters {
+		if s.parameters[i].parameter == p {
+			return &s.parameters[i]
+		}
+	}
+
+	s.parameters = append(s.parameters, parameterValue{parameter: p, dataMSB: unset, dataLSB: unset})
+	return &s.parameters[len(s.parameters)-1]
+}
+
+// Program returns the program of the given channel. ok is false, if it is unknown.
+func (t *Tracker) Program(ch uint8) (program uint8, ok bool) {
+	return toUint8(t.channels[ch&0x0F].program)
+}
+
+// Controller returns the value of the given controller of the given channel. ok is false, if it is unknown.
+// The data entry and parameter number controllers are not tracked as controllers (see RPN and NRPN).
+func (t *Tracker) Controller(ch, controller uint8) (value uint8, ok bool) {
+	return toUint8(t.channels[ch&0x0F].controllers[controller&0x7F])
+}
+
+// Pitchbend returns the pitch bend value of the given channel. ok is false, if it is unknown.
+func (t *Tracker) Pitchbend(ch uint8) (value int16, ok bool) {
+	value = t.channels[ch&0x0F].pitchbend
+	return value, value != pitchbendUnset
+}
+
+// Aftertouch returns the (channel) aftertouch of the given channel. ok is false, if it is unknown.
+func (t *Tracker) Aftertouch(ch uint8) (pressure uint8, ok bool) {
+	return toUint8(t.channels[ch&0x0F].aftertouch)
+}
+
+// PolyAftertouch returns the polyphonic aftertouch of the given key. ok is false, if it is unknown.
+func (t *Tracker) PolyAftertouch(ch, key uint8) (pressure uint8, ok bool) {
+	return toUint8(t.channels[ch&0x0F].polyAftertouch[key&0x7F])
+}
+
+// RPN returns the data entry value (MSB and LSB) of the given registered parameter. ok is false, if it is unknown.
+// An unknown LSB is returned as 0.
+func (t *Tracker) RPN(ch, paramMSB, paramLSB uint8) (msb, lsb uint8, ok bool) {
+	return t.channels[ch&0x0F].parameter(parameter{msb: int16(paramMSB), lsb: int16(paramLSB)})
+}
+
+// NRPN returns the data entry value (MSB and LSB) of the given non registered parameter. ok is false, if it is unknown.
+// An unknown LSB is returned as 0.
+func (t *Tracker) NRPN(ch, paramMSB, paramLSB uint8) (msb, lsb uint8, ok bool) {
+	return t.channels[ch&0x0F].parameter(parameter{nrpn: true, msb: int16(paramMSB), lsb: int16(paramLSB)})
+}
+
+func (s *state) parameter(p parameter) (msb, lsb uint8, ok bool) {
+	for _, v := range s.parameters {
+		if v.parameter == p && v.dataMSB != unset {
+			lsb, _ = toUint8(v.dataLSB)
+			return uint8(v.dataMSB), lsb, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Sustain returns true, if the sustain pedal of the given channel is down
+func (t *Tracker) Sustain(ch uint8) bool {
+	return t.channels[ch&0x0F].sustain()
+}
+
+// HeldNotes returns the keys of the given channel that are held, including the notes
+// that are kept by the sustain pedal. A key is returned once for each note on.
+func (t *Tracker) HeldNotes(ch uint8) (keys []uint8) {
+	s := &t.channels[ch&0x0F]
+
+	for key := range s.notes {
+		for i := 0; i < len(s.notes[key])+len(s.sustained[key]); i++ {
+			keys = append(keys, uint8(key))
+		}
+	}
+
+	return
+}
+
+func toUint8(v int16) (uint8, bool) {
+	if v < 0 {
+		return 0, false
+	}
+	return uint8(v), true
+}
@@ -0,0 +1,306 @@
+package midistate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midireader"
+	"github.com/gomidi/midi/midiwriter"
+)
+
+func printMessages(msgs []midi.Message) string {
+	var bf bytes.Buffer
+	for _, m := range msgs {
+		fmt.Fprintf(&bf, "%s\n", m)
+	}
+	return strings.TrimSpace(bf.String())
+}
+
+func track(msgs ...midi.Message) *Tracker {
+	t := New()
+	for _, m := range msgs {
+		t.Track(m)
+	}
+	return t
+}
+
+func TestMessages(t *testing.T) {
+	ch := channel.Channel1
+
+	tests := []struct {
+		descr    string
+		input    []midi.Message
+		expected string
+	}{
+		{
+			"empty",
+			nil,
+			"",
+		},
+		{
+			"last values in order",
+			[]midi.Message{
+				ch.Pitchbend(100),
+				ch.ControlChange(7, 80),
+				ch.ProgramChange(3),
+				ch.ControlChange(7, 90),
+				ch.ControlChange(0, 1),
+				ch.Aftertouch(20),
+				ch.Pitchbend(200),
+			},
+			`channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+channel.ProgramChange channel 1 program 3
+channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 90
+channel.Pitchbend channel 1 value 200 absValue 0
+channel.Aftertouch channel 1 pressure 20`,
+		},
+		{
+			"held notes",
+			[]midi.Message{
+				ch.NoteOn(60, 100),
+				ch.NoteOn(62, 90),
+				ch.NoteOn(60, 80),
+				ch.NoteOff(60),
+				ch.NoteOn(62, 0),
+				ch.PolyAftertouch(60, 30),
+			},
+			`channel.NoteOn channel 1 key 60 velocity 80
+channel.PolyAftertouch channel 1 key 60 pressure 30`,
+		},
+		{
+			"sustained notes",
+			[]midi.Message{
+				ch.NoteOn(60, 100),
+				ch.NoteOn(62, 90),
+				ch.ControlChange(64, 127),
+				ch.NoteOff(60),
+				ch.NoteOn(64, 70),
+			},
+			`channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 127
+channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOff channel 1 key 60
+channel.NoteOn channel 1 key 62 velocity 90
+channel.NoteOn channel 1 key 64 velocity 70`,
+		},
+		{
+			"sustain released",
+			[]midi.Message{
+				ch.NoteOn(60, 100),
+				ch.ControlChange(64, 127),
+				ch.NoteOff(60),
+				ch.ControlChange(64, 0),
+			},
+			`channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 0`,
+		},
+		{
+			"rpn",
+			[]midi.Message{
+				// pitch bend sensitivity
+				ch.ControlChange(101, 0),
+				ch.ControlChange(100, 0),
+				ch.ControlChange(6, 2),
+				ch.ControlChange(38, 0),
+				// fine tuning
+				ch.ControlChange(101, 0),
+				ch.ControlChange(100, 1),
+				ch.ControlChange(6, 64),
+				ch.ControlChange(96, 0),
+				// pitch bend sensitivity again
+				ch.ControlChange(101, 0),
+				ch.ControlChange(100, 0),
+				ch.ControlChange(6, 12),
+				ch.ControlChange(101, 127),
+				ch.ControlChange(100, 127),
+				// ignored
+				ch.ControlChange(6, 1),
+			},
+			`channel.ControlChange channel 1 controller 101 ("Registered Parameter (MSB)") value 0
+channel.ControlChange channel 1 controller 100 ("Registered Parameter (LSB)") value 0
+channel.ControlChange channel 1 controller 6 ("Data Entry (MSB)") value 12
+channel.ControlChange channel 1 controller 38 ("Data Entry (LSB)") value 0
+channel.ControlChange channel 1 controller 101 ("Registered Parameter (MSB)") value 0
+channel.ControlChange channel 1 controller 100 ("Registered Parameter (LSB)") value 1
+channel.ControlChange channel 1 controller 6 ("Data Entry (MSB)") value 64
+channel.ControlChange channel 1 controller 38 ("Data Entry (LSB)") value 1
+channel.ControlChange channel 1 controller 101 ("Registered Parameter (MSB)") value 127
+channel.ControlChange channel 1 controller 100 ("Registered Parameter (LSB)") value 127`,
+		},
+		{
+			"nrpn stays selected",
+			[]midi.Message{
+				ch.ControlChange(99, 1),
+				ch.ControlChange(98, 8),
+				ch.ControlChange(6, 70),
+			},
+			`channel.ControlChange channel 1 controller 99 ("Non-registered Parameter (MSB)") value 1
+channel.ControlChange channel 1 controller 98 ("Non-registered Parameter (LSB)") value 8
+channel.ControlChange channel 1 controller 6 ("Data Entry (MSB)") value 70`,
+		},
+		{
+			"all notes off and mode",
+			[]midi.Message{
+				ch.NoteOn(60, 100),
+				ch.ControlChange(123, 0),
+				ch.NoteOn(62, 100),
+				ch.ControlChange(125, 0),
+				ch.ControlChange(124, 0),
+				ch.NoteOn(64, 100),
+			},
+			`channel.ControlChange channel 1 controller 124 ("Omni Mode Off") value 0
+channel.NoteOn channel 1 key 64 velocity 100`,
+		},
+		{
+			"reset all controllers",
+			[]midi.Message{
+				ch.ControlChange(7, 80),
+				ch.ControlChange(64, 127),
+				ch.Pitchbend(100),
+				ch.PolyAftertouch(60, 20),
+				ch.ControlChange(121, 0),
+			},
+			`channel.ControlChange channel 1 controller 1 ("Modulation Wheel (MSB)") value 0
+channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+channel.ControlChange channel 1 controller 11 ("Expression (MSB)") value 127
+channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 0
+channel.ControlChange channel 1 controller 65 ("Portamento (on/off)") value 0
+channel.ControlChange channel 1 controller 66 ("Sustenuto Pedal (on/off)") value 0
+channel.ControlChange channel 1 controller 67 ("Soft Pedal (on/off)") value 0
+channel.ControlChange channel 1 controller 101 ("Registered Parameter (MSB)") value 127
+channel.ControlChange channel 1 controller 100 ("Registered Parameter (LSB)") value 127
+channel.Pitchbend channel 1 value 0 absValue 0
+channel.Aftertouch channel 1 pressure 0`,
+		},
+		{
+			"channels in order",
+			[]midi.Message{
+				channel.Channel9.ProgramChange(1),
+				channel.Channel2.ProgramChange(2),
+			},
+			`channel.ProgramChange channel 2 program 2
+channel.ProgramChange channel 9 program 1`,
+		},
+	}
+
+	for _, test := range tests {
+		got := printMessages(track(test.input...).Messages())
+
+		if got != test.expected {
+			t.Errorf("[%s] Messages() = \n%s\n\n// expected\n%s", test.descr, got, test.expected)
+		}
+	}
+}
+
+func TestReproduce(t *testing.T) {
+	ch := channel.Channel3
+	input := []midi.Message{
+		ch.ControlChange(0, 2),
+		ch.ProgramChange(5),
+		ch.ControlChange(101, 0),
+		ch.ControlChange(100, 0),
+		ch.ControlChange(6, 3),
+		ch.ControlChange(64, 100),
+		ch.NoteOn(50, 60),
+		ch.NoteOn(52, 61),
+		ch.NoteOff(50),
+		ch.Pitchbend(-300),
+		ch.Aftertouch(4),
+	}
+
+	original := track(input...)
+	reproduced := track(original.Messages()...)
+
+	if got, expected := printMessages(reproduced.Messages()), printMessages(original.Messages()); got != expected {
+		t.Errorf("reproduced state differs:\n%s\n\n// expected\n%s", got, expected)
+	}
+
+	if p, ok := reproduced.Program(3); !ok || p != 5 {
+		t.Errorf("Program(3) = %v, %v // expected 5, true", p, ok)
+	}
+
+	if msb, lsb, ok := reproduced.RPN(3, 0, 0); !ok || msb != 3 || lsb != 0 {
+		t.Errorf("RPN(3, 0, 0) = %v, %v, %v // expected 3, 0, true", msb, lsb, ok)
+	}
+
+	if !reproduced.Sustain(3) {
+		t.Errorf("Sustain(3) = false // expected true")
+	}
+
+	if got := fmt.Sprint(reproduced.HeldNotes(3)); got != "[50 52]" {
+		t.Errorf("HeldNotes(3) = %s // expected [50 52]", got)
+	}
+
+	if _, ok := reproduced.Controller(3, 7); ok {
+		t.Errorf("Controller(3, 7) must be unknown")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	ch := channel.Channel0
+	tr := track(ch.NoteOn(60, 100), ch.ControlChange(99, 0), ch.ControlChange(98, 1), ch.ControlChange(6, 10))
+	snapshot := tr.Copy()
+	expected := printMessages(snapshot.Messages())
+
+	tr.Track(ch.NoteOff(60))
+	tr.Track(ch.ControlChange(6, 20))
+	tr.Track(ch.NoteOn(61, 100))
+
+	if got := printMessages(snapshot.Messages()); got != expected {
+		t.Errorf("snapshot has been changed:\n%s\n\n// expected\n%s", got, expected)
+	}
+}
+
+func TestNoteOffs(t *testing.T) {
+	ch := channel.Channel1
+	tr := track(ch.NoteOn(60, 100), ch.NoteOn(60, 100), ch.ControlChange(64, 127), ch.NoteOn(62, 100), channel.Channel2.NoteOn(10, 1))
+
+	expected := `channel.NoteOff channel 1 key 60
+channel.NoteOff channel 1 key 60
+channel.NoteOff channel 1 key 62
+channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 0
+channel.NoteOff channel 2 key 10`
+
+	if got := printMessages(tr.NoteOffs()); got != expected {
+		t.Errorf("NoteOffs() = \n%s\n\n// expected\n%s", got, expected)
+	}
+
+	for _, m := range tr.NoteOffs() {
+		tr.Track(m)
+	}
+
+	if got := printMessages(tr.NoteOffs()); got != "" {
+		t.Errorf("notes are still held after NoteOffs:\n%s", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tr := track(channel.Channel4.ProgramChange(7), channel.Channel4.NoteOn(60, 90))
+
+	var bf bytes.Buffer
+	err := tr.Write(midiwriter.New(&bf))
+
+	if err != nil {
+		t.Fatalf("can't write: %v", err)
+	}
+
+	rd := midireader.New(&bf, nil)
+	var msgs []midi.Message
+
+	for {
+		m, err := rd.Read()
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, m)
+	}
+
+	expected := `channel.ProgramChange channel 4 program 7
+channel.NoteOn channel 4 key 60 velocity 90`
+
+	if got := printMessages(msgs); got != expected {
+		t.Errorf("written:\n%s\n\n// expected\n%s", got, expected)
+	}
+}
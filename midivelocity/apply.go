@@ -0,0 +1,52 @@
+package midivelocity
+
+import (
+	"github.com/gomidi/midi/midiroute"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// Zone applies a Mapper to the keys from Low to High (inclusive)
+type Zone struct {
+	Low, High uint8
+	Mapper    Mapper
+}
+
+// Zones apply different Mappers to different key ranges
+type Zones []Zone
+
+// Map applies the Mapper of the first zone that contains the key.
+// The velocities of keys outside of all zones are not changed.
+func (z Zones) Map(key, velocity uint8) uint8 {
+	for _, zone := range z {
+		if key >= zone.Low && key <= zone.High {
+			return zone.Mapper.Map(key, velocity)
+		}
+	}
+	return velocity
+}
+
+// Transform returns a midiroute.Transform that changes the velocity of note on messages with the given Mapper.
+// The velocity is kept in the range 1-127 (see midiroute.Velocity).
+func Transform(m Mapper) midiroute.Transform {
+	return midiroute.VelocityByKey(m.Map)
+}
+
+// ApplySMF returns a copy of the SMF with the velocities of the note on messages of all tracks changed by the given Mapper
+func ApplySMF(s *smftrack.SMF, m Mapper) *smftrack.SMF {
+	res := &smftrack.SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	tr := Transform(m)
+
+	for _, t := range s.Tracks {
+		nt := &smftrack.Track{End: t.End, Events: make([]smftrack.Event, len(t.Events))}
+
+		for i, ev := range t.Events {
+			ev.Message = tr(ev.Message)
+			nt.Events[i] = ev
+		}
+
+		res.Tracks = append(res.Tracks, nt)
+	}
+
+	return res
+}
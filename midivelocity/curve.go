@@ -0,0 +1,208 @@
+package midivelocity
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Mapper maps the velocity of a note on message with the given key to a new velocity
+type Mapper interface {
+	Map(key, velocity uint8) uint8
+}
+
+// Curve maps each velocity (the index) to a new velocity. The entry for velocity 0 is not used.
+type Curve [128]uint8
+
+// Map returns the velocity of the curve for the given velocity; the key is ignored.
+// Velocity 0 stays 0, other velocities are kept in the range 1-127.
+func (c Curve) Map(key, velocity uint8) uint8 {
+	if velocity == 0 {
+		return 0
+	}
+	return clamp(float64(c[velocity&0x7F]))
+}
+
+// Then returns a curve that applies the curve next to the result of c
+func (c Curve) Then(next Curve) Curve {
+	var res Curve
+	for v := 1; v < 128; v++ {
+		res[v] = next.Map(0, c.Map(0, uint8(v)))
+	}
+	return res
+}
+
+// Save writes the curve in the text format (see LoadCurve)
+func (c Curve) Save(w io.Writer) error {
+	for v := 1; v < 128; v++ {
+		if _, err := fmt.Fprintf(w, "%v %v\n", v, c.Map(0, uint8(v))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadCurve reads a curve in the text format: each line has an input velocity and an output velocity,
+// separated by whitespace. Empty lines and lines starting with # are ignored. The input velocities
+// must be in ascending order. The velocities between two lines are interpolated linearly, the velocities
+// below the first line get the output of the first line and the velocities above the last line get the output of the last line.
+func LoadCurve(r io.Reader) (Curve, error) {
+	var c Curve
+	var ins, outs []float64
+
+	sc := bufio.NewScanner(r)
+	line := 0
+
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+
+		if s == "" || s[0] == '#' {
+			continue
+		}
+
+		fields := strings.Fields(s)
+
+		if len(fields) != 2 {
+			return c, fmt.Errorf("line %v: expected input and output velocity, got %q", line, s)
+		}
+
+		var vals [2]float64
+
+		for i, f := range fields {
+			v, err := strconv.ParseUint(f, 10, 8)
+
+			if err != nil || v > 127 {
+				return c, fmt.Errorf("line %v: invalid velocity %q", line, f)
+			}
+
+			vals[i] = float64(v)
+		}
+
+		if len(ins) > 0 && vals[0] <= ins[len(ins)-1] {
+			return c, fmt.Errorf("line %v: input velocity %v is not ascending", line, vals[0])
+		}
+
+		ins = append(ins, vals[0])
+		outs = append(outs, vals[1])
+	}
+
+	if err := sc.Err(); err != nil {
+		return c, err
+	}
+
+	if len(ins) == 0 {
+		return c, fmt.Errorf("no velocities defined")
+	}
+
+	i := 0
+
+	for v := 1; v < 128; v++ {
+		x := float64(v)
+
+		for i < len(ins)-1 && x > ins[i+1] {
+			i++
+		}
+
+		switch {
+		case x <= ins[0]:
+			c[v] = clamp(outs[0])
+		case i == len(ins)-1:
+			c[v] = clamp(outs[i])
+		default:
+			c[v] = clamp(outs[i] + (outs[i+1]-outs[i])*(x-ins[i])/(ins[i+1]-ins[i]))
+		}
+	}
+
+	return c, nil
+}
+
+// newCurve returns a curve with the velocities calculated by fn
+func newCurve(fn func(velocity float64) float64) Curve {
+	var c Curve
+	for v := 1; v < 128; v++ {
+		c[v] = clamp(fn(float64(v)))
+	}
+	return c
+}
+
+// Linear returns a curve that maps the velocities 1-127 linearly to the range from min to max
+// (Linear(1, 127) does not change the velocities).
+func Linear(min, max uint8) Curve {
+	return newCurve(func(v float64) float64 {
+		return float64(min) + (float64(max)-float64(min))*(v-1)/126
+	})
+}
+
+// Exponential returns a curve that makes the low and middle velocities softer.
+// The greater the amount, the steeper the curve. An amount <= 0 does not change the velocities.
+func Exponential(amount float64) Curve {
+	if amount <= 0 {
+		return Linear(1, 127)
+	}
+
+	return newCurve(func(v float64) float64 {
+		return 127 * (math.Exp(amount*v/127) - 1) / (math.Exp(amount) - 1)
+	})
+}
+
+// Logarithmic returns a curve that makes the low and middle velocities louder.
+// The greater the amount, the steeper the curve. An amount <= 0 does not change the velocities.
+func Logarithmic(amount float64) Curve {
+	if amount <= 0 {
+		return Linear(1, 127)
+	}
+
+	return newCurve(func(v float64) float64 {
+		return 127 * math.Log1p(amount*v/127) / math.Log1p(amount)
+	})
+}
+
+// Fixed returns a curve that maps all velocities to the given velocity
+func Fixed(velocity uint8) Curve {
+	return newCurve(func(float64) float64 {
+		return float64(velocity)
+	})
+}
+
+// Compress returns a curve that reduces the distance of the velocities above the threshold
+// to the threshold by the given ratio (e.g. 2 halves it). The velocities below the threshold are not changed.
+// A ratio < 1 increases the distance (upward expansion).
+func Compress(threshold uint8, ratio float64) Curve {
+	t := float64(threshold)
+
+	return newCurve(func(v float64) float64 {
+		if v <= t || ratio <= 0 {
+			return v
+		}
+		return t + (v-t)/ratio
+	})
+}
+
+// Expand returns a curve that multiplies the distance of the velocities below the threshold
+// to the threshold by the given ratio (e.g. 2 doubles it), so that soft notes become softer.
+// The velocities above the threshold are not changed.
+func Expand(threshold uint8, ratio float64) Curve {
+	t := float64(threshold)
+
+	return newCurve(func(v float64) float64 {
+		if v >= t || ratio <= 0 {
+			return v
+		}
+		return t - (t-v)*ratio
+	})
+}
+
+// clamp rounds the velocity and keeps it in the range 1-127
+func clamp(v float64) uint8 {
+	switch {
+	case v < 1:
+		return 1
+	case v > 127:
+		return 127
+	}
+	return uint8(math.Round(v))
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midivelocity provides velocity curves and dynamics processing for note on messages.
+
+A Curve is a table that maps each velocity to a new velocity. Curves are created by functions
+(linear, exponential, logarithmic, fixed, compression and expansion), combined with Then or
+loaded from a simple text format, where each line has an input velocity and an output velocity:
+
+	# input output
+	1   20
+	64  90
+	127 127
+
+The velocities between the lines are interpolated.
+
+Zones apply different curves to different key ranges. Curves and Zones are Mappers that can be
+used for live streams (see Transform) and for SMF files (see ApplySMF).
+A note on message with velocity 0 is a note off and not changed. Other velocities stay in the range 1-127.
+
+Usage
+
+	import (
+		"github.com/gomidi/midi/midireader"
+		"github.com/gomidi/midi/midiroute"
+		"github.com/gomidi/midi/midivelocity"
+		"github.com/gomidi/midi/midiwriter"
+	)
+
+	// softer keys on the left, compressed dynamics on the right
+	zones := midivelocity.Zones{
+		{Low: 0, High: 59, Mapper: midivelocity.Exponential(2)},
+		{Low: 60, High: 127, Mapper: midivelocity.Compress(80, 3)},
+	}
+
+	err := midiroute.Pipe(
+		midireader.New(keyboard, nil),
+		midiwriter.New(out),
+		midivelocity.Transform(zones),
+	)
+
+*/
+package midivelocity
@@ -0,0 +1,154 @@
+package midivelocity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+func TestCurves(t *testing.T) {
+	tests := []struct {
+		descr    string
+		curve    Curve
+		in       []uint8
+		expected []uint8
+	}{
+		{"linear identity", Linear(1, 127), []uint8{0, 1, 64, 127}, []uint8{0, 1, 64, 127}},
+		{"linear range", Linear(20, 100), []uint8{1, 64, 127}, []uint8{20, 60, 100}},
+		{"exponential", Exponential(2), []uint8{1, 64, 100, 127}, []uint8{1, 35, 76, 127}},
+		{"logarithmic", Logarithmic(2), []uint8{1, 64, 100, 127}, []uint8{2, 81, 109, 127}},
+		{"exponential amount 0", Exponential(0), []uint8{1, 64, 127}, []uint8{1, 64, 127}},
+		{"fixed", Fixed(90), []uint8{0, 1, 64, 127}, []uint8{0, 90, 90, 90}},
+		{"compress", Compress(80, 3), []uint8{50, 80, 110, 127}, []uint8{50, 80, 90, 96}},
+		{"compress upwards", Compress(100, 0.5), []uint8{50, 110, 127}, []uint8{50, 120, 127}},
+		{"expand", Expand(60, 2), []uint8{10, 40, 60, 100}, []uint8{1, 20, 60, 100}},
+		{"then", Compress(80, 3).Then(Linear(1, 63)), []uint8{1, 127}, []uint8{1, 48}},
+		{"empty table", Curve{}, []uint8{0, 1, 127}, []uint8{0, 1, 1}},
+	}
+
+	for _, test := range tests {
+		for i, in := range test.in {
+			if got, want := test.curve.Map(60, in), test.expected[i]; got != want {
+				t.Errorf("[%s] velocity %v: got %v wanted %v", test.descr, in, got, want)
+			}
+		}
+	}
+}
+
+func TestLoadCurve(t *testing.T) {
+	text := `
+# a soft curve
+10 20
+  64   90
+
+127 127
+`
+
+	c, err := LoadCurve(strings.NewReader(text))
+
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	for in, want := range map[uint8]uint8{1: 20, 10: 20, 37: 55, 64: 90, 100: 111, 127: 127} {
+		if got := c.Map(0, in); got != want {
+			t.Errorf("velocity %v: got %v wanted %v", in, got, want)
+		}
+	}
+
+	var bf bytes.Buffer
+
+	if err := c.Save(&bf); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	loaded, err := LoadCurve(&bf)
+
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	if loaded != c {
+		t.Errorf("saved and loaded curve differs:\n%v\n%v", loaded, c)
+	}
+}
+
+func TestLoadCurveErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"10 20\n5 30", "line 2: input velocity 5 is not ascending"},
+		{"10 20 30", `line 1: expected input and output velocity, got "10 20 30"`},
+		{"10 128", `line 1: invalid velocity "128"`},
+		{"# nothing", "no velocities defined"},
+	}
+
+	for _, test := range tests {
+		_, err := LoadCurve(strings.NewReader(test.text))
+
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%q: got error %v wanted %v", test.text, err, test.expected)
+		}
+	}
+}
+
+func TestZones(t *testing.T) {
+	z := Zones{
+		{Low: 0, High: 59, Mapper: Fixed(30)},
+		{Low: 60, High: 72, Mapper: Linear(100, 127)},
+	}
+
+	tr := Transform(z)
+	ch := channel.Channel1
+
+	tests := []struct {
+		in       channel.NoteOn
+		expected string
+	}{
+		{ch.NoteOn(59, 100), "channel.NoteOn channel 1 key 59 velocity 30"},
+		{ch.NoteOn(60, 1), "channel.NoteOn channel 1 key 60 velocity 100"},
+		{ch.NoteOn(73, 64), "channel.NoteOn channel 1 key 73 velocity 64"},
+		{ch.NoteOn(59, 0), "channel.NoteOn channel 1 key 59 velocity 0"},
+	}
+
+	for _, test := range tests {
+		if got := tr(test.in).String(); got != test.expected {
+			t.Errorf("got: %s wanted: %s", got, test.expected)
+		}
+	}
+}
+
+func TestApplySMF(t *testing.T) {
+	var tr smftrack.Track
+	ch := channel.Channel0
+
+	tr.Add(0, ch.NoteOn(60, 100), ch.ControlChange(7, 80))
+	tr.Add(10, ch.NoteOff(60))
+	s := &smftrack.SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*smftrack.Track{&tr}}
+
+	res := ApplySMF(s, Fixed(64))
+
+	var got []string
+
+	for _, ev := range res.Tracks[0].Events {
+		got = append(got, ev.Message.String())
+	}
+
+	expected := `channel.NoteOn channel 0 key 60 velocity 64
+channel.ControlChange channel 0 controller 7 ("Volume (MSB)") value 80
+channel.NoteOff channel 0 key 60`
+
+	if s := strings.Join(got, "\n"); s != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", s, expected)
+	}
+
+	// the original is not changed
+	if vel := tr.Events[0].Message.(channel.NoteOn).Velocity(); vel != 100 {
+		t.Errorf("original velocity changed to %v", vel)
+	}
+}
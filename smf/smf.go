@@ -41,6 +41,7 @@ type Writer interface {
 	//   If the last track has been written, io.EOF will be returned. (Also for any further attempt to write).
 	// - It is the responsibility of the caller to make sure the provided NumTracks (which defaults to 1) is not
 	//   larger as the number of tracks in the file.
+	//   (smfwriter.DynamicTracks lifts this restriction by writing the number of tracks when the writer is closed).
 	// Any error stops the writing, is tracked and prohibits further writing.
 	// At the end smf.ErrFinished will be returned
 	Write(midi.Message) error
@@ -61,6 +62,7 @@ type Reader interface {
 
 	// Read reads a MIDI message from a SMF file.
 	// any error will be tracked and stops reading and prevents any other attempt to read.
+	// Errors while reading the data are returned as *midi.ReadError that carries the position of the failing message.
 	// At the end, smf.ErrFinished will be returned.
 	Read() (midi.Message, error)
 
@@ -372,5 +374,5 @@ func (f format) String() string {
 	case SMF2:
 		return "SMF2 (sequential tracks)"
 	}
-	panic("unreachable")
+	return fmt.Sprintf("unknown SMF format (%v)", uint16(f))
 }
@@ -7,6 +7,10 @@ var (
 	errExpectedMthd          = errors.New("Expected SMF Midi header.")
 	errBadSizeChunk          = errors.New("Chunk was an unexpected size.")
 	errInterruptedByCallback = errors.New("interrupted by callback")
+	errInvalidSysExStart     = errors.New("sysex in SMF must start with F0 or F7")
+	errEmptySysEx            = errors.New("sysex starting with F0 must not be empty")
+	errInvalidStatus         = errors.New("invalid status byte")
+	errUnknownEvent          = errors.New("unknown event")
 	// ErrMissing is the error returned, if there is no more data, but tracks are missing
 	ErrMissing = errors.New("incomplete, tracks missing")
 )
@@ -0,0 +1,150 @@
+package smfreader
+
+import (
+	"io"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/midilib"
+)
+
+var _ midi.EventReader = &reader{}
+
+// ReadEvent reads the next MIDI message into ev. It is an alternative to Read that does not allocate
+// (apart from growing ev.Payload for sysex and meta messages).
+// Delta and Track report the position of the event, as they do after Read.
+// The errors are the same as for Read.
+func (r *reader) ReadEvent(ev *midi.Event) error {
+	err := r.readEventInto(ev)
+	if err == io.EOF && r.tracksMissing() {
+		return ErrMissing
+	}
+	return err
+}
+
+func (r *reader) readEventInto(ev *midi.Event) (err error) {
+	err = r.prepare()
+
+	if err != nil {
+		return err
+	}
+
+	var canary byte
+	canary, err = r.readDelta()
+
+	if err == nil {
+		err = r._readEventInto(canary, ev)
+	}
+
+	// the event has been started with the delta, so the end of file comes unexpected
+	if err == io.EOF {
+		err = midi.ErrUnexpectedEOF
+	}
+
+	r.error = r.newError(err)
+	return r.error
+}
+
+// _readEventInto is the counterpart of _readEvent for a reusable midi.Event
+func (r *reader) _readEventInto(canary byte, ev *midi.Event) (err error) {
+	status, changed := r.runningStatus.Read(canary)
+
+	r.status = status
+	if status == 0 {
+		r.status = canary
+	}
+
+	// on a voice/channel category message with status either given or cached (running status)
+	if status != 0 {
+		var arg1 = canary // assume running status - we already got arg1
+
+		// was no running status, we have to read arg1
+		if changed {
+			arg1, err = midilib.ReadByte(r.input)
+			if err != nil {
+				return
+			}
+		}
+
+		return midilib.ReadChannelEvent(ev, status, arg1, r.input)
+	}
+
+	ev.Status = canary
+	ev.Channel = 0
+	ev.Data1 = 0
+	ev.Data2 = 0
+	ev.MetaType = 0
+
+	switch canary {
+
+	// both 0xF0 and 0xF7 may start a sysex in SMF files
+	case 0xF0, 0xF7:
+		ev.Kind = midi.KindSysEx
+		escape := canary == 0xF7 && !r.sysexreader.inSequence
+		ev.Payload, err = r.readPayload(ev.Payload[:0])
+
+		if err != nil {
+			return
+		}
+
+		// see sysexReader.Read
+		last := len(ev.Payload) - 1
+
+		if canary == 0xF0 {
+			if last < 0 {
+				return errEmptySysEx
+			}
+			r.sysexreader.inSequence = ev.Payload[last] != 0xF7
+		} else if last >= 0 && ev.Payload[last] == 0xF7 {
+			r.sysexreader.inSequence = false
+		}
+
+		if escape && r.unescape {
+			unescapeEvent(ev)
+		}
+
+		return nil
+
+	// meta event
+	case 0xFF:
+		ev.Kind = midi.KindMeta
+		ev.MetaType, err = midilib.ReadByte(r.input)
+
+		if err != nil {
+			return
+		}
+
+		ev.Payload, err = r.readPayload(ev.Payload[:0])
+
+		if err != nil {
+			return
+		}
+
+		// end of track
+		if ev.MetaType == 0x2F {
+			r.endOfTrack()
+		}
+
+		return nil
+
+	default:
+		// data byte without running status or a message that is not allowed within SMF
+		return errInvalidStatus
+	}
+}
+
+// readPayload reads the length and the data of a meta or sysex message and appends the data to dst
+func (r *reader) readPayload(dst []byte) ([]byte, error) {
+	length, err := midilib.ReadVarLength(r.input)
+
+	if err != nil {
+		return dst, err
+	}
+
+	err = r.limits.payload(length)
+
+	if err != nil {
+		return dst, err
+	}
+
+	return midilib.ReadDataInto(dst, r.input, length)
+}
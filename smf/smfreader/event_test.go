@@ -0,0 +1,161 @@
+package smfreader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+func mkSysExSMF() []byte {
+	var bf bytes.Buffer
+
+	wr := smfwriter.New(&bf)
+	wr.Write(sysex.Escape(realtime.Start.Raw()))
+	wr.Write(channel.Channel2.NoteOn(65, 90))
+	wr.SetDelta(10)
+	wr.Write(sysex.SysEx([]byte{0x90, 0x51}))
+	wr.SetDelta(1)
+	wr.Write(channel.Channel2.NoteOffVelocity(65, 30))
+	wr.Write(sysex.Start([]byte{0x90, 0x51}))
+	wr.SetDelta(5)
+	wr.Write(sysex.Continue([]byte{0x90, 0x51}))
+	wr.SetDelta(5)
+	wr.Write(sysex.End([]byte{0x90, 0x51}))
+	wr.Write(meta.EndOfTrack)
+
+	return bf.Bytes()
+}
+
+func TestReadEvent(t *testing.T) {
+	tests := []struct {
+		descr string
+		input []byte
+	}{
+		{"SMF0", examples.SpecSMF0},
+		{"SMF1", examples.SpecSMF1},
+		{"SMF1 missing track", examples.SpecSMF1Missing},
+		{"sysex", mkSysExSMF()},
+	}
+
+	for _, test := range tests {
+		rd := New(bytes.NewReader(test.input), NoteOffVelocity())
+		evrd := New(bytes.NewReader(test.input))
+
+		var ev midi.Event
+
+		for i := 0; ; i++ {
+			m, err := rd.Read()
+			everr := evrd.(midi.EventReader).ReadEvent(&ev)
+
+			if (err == nil) != (everr == nil) || (err != nil && err.Error() != everr.Error()) {
+				t.Fatalf("[%s] [%v] ReadEvent returned error %v, Read returned %v", test.descr, i, everr, err)
+			}
+
+			if err != nil {
+				break
+			}
+
+			if got, expected := ev.AppendRaw(nil), m.Raw(); !bytes.Equal(got, expected) {
+				t.Errorf("[%s] [%v] ReadEvent got % X, Read got % X (%s)", test.descr, i, got, expected, m)
+			}
+
+			if evrd.Delta() != rd.Delta() || evrd.Track() != rd.Track() {
+				t.Errorf("[%s] [%v] ReadEvent got track %v delta %v, Read got track %v delta %v", test.descr, i, evrd.Track(), evrd.Delta(), rd.Track(), rd.Delta())
+			}
+		}
+	}
+}
+
+func TestReadEventAllocs(t *testing.T) {
+	var bf bytes.Buffer
+
+	wr := smfwriter.New(&bf)
+
+	for i := 0; i < 101*100; i++ {
+		wr.SetDelta(10)
+		wr.Write(channel.Channel2.NoteOn(65, 90))
+		wr.SetDelta(10)
+		wr.Write(channel.Channel2.NoteOff(65))
+		wr.Write(meta.Text("text"))
+	}
+
+	wr.Write(meta.EndOfTrack)
+
+	rd := New(bytes.NewReader(bf.Bytes())).(midi.EventReader)
+
+	var ev midi.Event
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for j := 0; j < 300; j++ {
+			if err := rd.ReadEvent(&ev); err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("ReadEvent allocates %v times per 300 messages, expected 0", allocs)
+	}
+}
+
+// mkNotesSMF returns a SMF with 1000 note on, note off and text messages
+func mkNotesSMF() []byte {
+	var bf bytes.Buffer
+
+	wr := smfwriter.New(&bf)
+
+	for i := 0; i < 1000; i++ {
+		wr.SetDelta(10)
+		wr.Write(channel.Channel2.NoteOn(65, 90))
+		wr.SetDelta(10)
+		wr.Write(channel.Channel2.NoteOff(65))
+		wr.Write(meta.Text("text"))
+	}
+
+	wr.Write(meta.EndOfTrack)
+
+	return bf.Bytes()
+}
+
+// BenchmarkRead reads 3000 messages per iteration via the midi.Message interface
+func BenchmarkRead(b *testing.B) {
+	data := mkNotesSMF()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rd := New(bytes.NewReader(data))
+		for j := 0; j < 3000; j++ {
+			if _, err := rd.Read(); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	}
+}
+
+// BenchmarkReadEvent is like BenchmarkRead but reads via ReadEvent into a reused midi.Event
+func BenchmarkReadEvent(b *testing.B) {
+	data := mkNotesSMF()
+
+	var ev midi.Event
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rd := New(bytes.NewReader(data)).(midi.EventReader)
+		for j := 0; j < 3000; j++ {
+			if err := rd.ReadEvent(&ev); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	}
+}
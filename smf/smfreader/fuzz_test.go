@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+package smfreader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+)
+
+// FuzzRead makes sure, that no input can make the reader panic or loop forever.
+func FuzzRead(f *testing.F) {
+	f.Add(examples.SpecSMF0)
+	f.Add(examples.SpecSMF1)
+	f.Add(examples.SpecSMF1Missing)
+	f.Add([]byte{0x4D, 0x54, 0x68, 0x64, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x01, 0x00, 0x60, 0x4D, 0x54, 0x72, 0x6B, 0x00, 0x00, 0x00, 0x04, 0x00, 0xF0, 0x00, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rd := New(bytes.NewReader(data))
+
+		var err error
+
+		// every message consumes at least one byte
+		for i := 0; i <= len(data); i++ {
+			_, err = rd.Read()
+			if err != nil {
+				return
+			}
+		}
+
+		t.Fatalf("reading did not stop after %v messages", len(data)+1)
+	})
+}
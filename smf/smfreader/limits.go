@@ -0,0 +1,101 @@
+package smfreader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gomidi/midi/internal/midilib"
+)
+
+// LimitError is returned (wrapped inside a *midi.ReadError), if the SMF data exceeds
+// one of the limits that have been set via options.
+type LimitError struct {
+	// Limit is the name of the option that set the limit, e.g. "MaxChunkSize"
+	Limit string
+
+	// Max is the value of the limit
+	Max uint64
+
+	// Value is the value that exceeded the limit
+	Value uint64
+}
+
+// Error returns the error message
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("limit %s exceeded: %v > %v", e.Limit, e.Value, e.Max)
+}
+
+// limits are the limits for reading untrusted data. 0 means no limit.
+type limits struct {
+	maxChunkSize      uint32
+	maxPayloadSize    uint32
+	maxEventsPerTrack uint32
+	maxTracks         uint16
+	maxAllocation     uint64
+
+	// counters
+	events    uint32
+	allocated uint64
+}
+
+func (l *limits) checkPayloads() bool {
+	return l.maxPayloadSize > 0 || l.maxAllocation > 0
+}
+
+func (l *limits) chunk(length uint32) error {
+	if l.maxChunkSize > 0 && length > l.maxChunkSize {
+		return &LimitError{"MaxChunkSize", uint64(l.maxChunkSize), uint64(length)}
+	}
+	return nil
+}
+
+func (l *limits) tracks(num uint16) error {
+	if l.maxTracks > 0 && num > l.maxTracks {
+		return &LimitError{"MaxTracks", uint64(l.maxTracks), uint64(num)}
+	}
+	return nil
+}
+
+func (l *limits) event() error {
+	l.events++
+	if l.maxEventsPerTrack > 0 && l.events > l.maxEventsPerTrack {
+		return &LimitError{"MaxEventsPerTrack", uint64(l.maxEventsPerTrack), uint64(l.events)}
+	}
+	return nil
+}
+
+func (l *limits) payload(length uint32) error {
+	if l.maxPayloadSize > 0 && length > l.maxPayloadSize {
+		return &LimitError{"MaxPayloadSize", uint64(l.maxPayloadSize), uint64(length)}
+	}
+
+	l.allocated += uint64(length)
+	if l.maxAllocation > 0 && l.allocated > l.maxAllocation {
+		return &LimitError{"MaxAllocation", l.maxAllocation, l.allocated}
+	}
+	return nil
+}
+
+// payloadReader reads the length of the following payload (meta or sysex data) and checks it against the limits.
+// The returned reader starts again with the length, so that it can be passed to the message readers.
+func (r *reader) payloadReader() (io.Reader, error) {
+	if !r.limits.checkPayloads() {
+		return r.input, nil
+	}
+
+	var bf bytes.Buffer
+	length, err := midilib.ReadVarLength(io.TeeReader(r.input, &bf))
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.limits.payload(length)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.MultiReader(&bf, r.input), nil
+}
@@ -0,0 +1,87 @@
+package smfreader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestLimits(t *testing.T) {
+	header := []byte{0x4D, 0x54, 0x68, 0x64, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x01, 0x00, 0x60}
+
+	// a text meta message that claims to be 256MB long
+	hugeText := append([]byte{}, header...)
+	hugeText = append(hugeText, 0x4D, 0x54, 0x72, 0x6B, 0x00, 0x00, 0x00, 0x0B, 0x00, 0xFF, 0x01, 0xFF, 0xFF, 0xFF, 0x7F, 0x41, 0x42, 0x43, 0x44)
+
+	// a sysex that claims to be 1MB long
+	hugeSysex := append([]byte{}, header...)
+	hugeSysex = append(hugeSysex, 0x4D, 0x54, 0x72, 0x6B, 0x00, 0x00, 0x00, 0x08, 0x00, 0xF0, 0xC0, 0x80, 0x00, 0x41, 0x42, 0x43)
+
+	tests := []struct {
+		descr string
+		input []byte
+		opt   Option
+		limit string
+	}{
+		{"tracks", examples.SpecSMF1, MaxTracks(3), "MaxTracks"},
+		{"chunk size", examples.SpecSMF0, MaxChunkSize(50), "MaxChunkSize"},
+		{"events per track", examples.SpecSMF1, MaxEventsPerTrack(4), "MaxEventsPerTrack"},
+		{"meta payload", hugeText, MaxPayloadSize(1024), "MaxPayloadSize"},
+		{"sysex payload", hugeSysex, MaxPayloadSize(1024), "MaxPayloadSize"},
+		{"allocation", hugeSysex, MaxAllocation(1024), "MaxAllocation"},
+	}
+
+	for _, test := range tests {
+		rd := New(bytes.NewReader(test.input), test.opt)
+
+		var err error
+
+		for err == nil {
+			_, err = rd.Read()
+		}
+
+		rerr, is := err.(*midi.ReadError)
+
+		if !is {
+			t.Errorf("[%s] expected *midi.ReadError, got: %#v", test.descr, err)
+			continue
+		}
+
+		lerr, is := rerr.Err.(*LimitError)
+
+		if !is {
+			t.Errorf("[%s] expected *LimitError, got: %#v", test.descr, rerr.Err)
+			continue
+		}
+
+		if got, want := lerr.Limit, test.limit; got != want {
+			t.Errorf("[%s] Limit = %q; want %q", test.descr, got, want)
+		}
+	}
+}
+
+func TestLimitsNotExceeded(t *testing.T) {
+	rd := New(bytes.NewReader(examples.SpecSMF1),
+		MaxTracks(4), MaxChunkSize(50), MaxEventsPerTrack(6), MaxPayloadSize(10), MaxAllocation(100),
+	)
+
+	var err error
+	var n int
+
+	for err == nil {
+		_, err = rd.Read()
+		n++
+	}
+
+	if err != io.EOF && err != smf.ErrFinished {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := n-1, 17; got != want {
+		t.Errorf("read %v messages; want %v", got, want)
+	}
+}
@@ -22,6 +22,60 @@ func NoteOffVelocity() Option {
 	}
 }
 
+// UnescapeRealtimeSysCommon lets the reader return sysex escapes (F7 <length> <bytes>) that contain
+// a single realtime or system common message as that message (see smfwriter.EscapeRealtimeSysCommon).
+// Other escapes are returned as sysex.Escape.
+func UnescapeRealtimeSysCommon() Option {
+	return func(rd *reader) {
+		rd.unescape = true
+	}
+}
+
+// MaxChunkSize limits the size of the chunks (tracks and unknown chunks) to the given number of bytes.
+// Chunks that announce a larger size result in a *LimitError.
+// If this option is not set (or max is 0), there is no limit.
+func MaxChunkSize(max uint32) Option {
+	return func(rd *reader) {
+		rd.limits.maxChunkSize = max
+	}
+}
+
+// MaxPayloadSize limits the size of the data of meta and sysex messages to the given number of bytes.
+// Messages that announce a larger size result in a *LimitError before any data is allocated.
+// If this option is not set (or max is 0), there is no limit.
+func MaxPayloadSize(max uint32) Option {
+	return func(rd *reader) {
+		rd.limits.maxPayloadSize = max
+	}
+}
+
+// MaxEventsPerTrack limits the number of events (MIDI messages) within a single track.
+// Reading more events from a track results in a *LimitError.
+// If this option is not set (or max is 0), there is no limit.
+func MaxEventsPerTrack(max uint32) Option {
+	return func(rd *reader) {
+		rd.limits.maxEventsPerTrack = max
+	}
+}
+
+// MaxTracks limits the number of tracks, the header may announce.
+// A header announcing more tracks results in a *LimitError.
+// If this option is not set (or max is 0), there is no limit.
+func MaxTracks(max uint16) Option {
+	return func(rd *reader) {
+		rd.limits.maxTracks = max
+	}
+}
+
+// MaxAllocation limits the total number of bytes, that are allocated for the data of all meta and sysex messages of the file.
+// Exceeding this limit results in a *LimitError before the data is allocated.
+// If this option is not set (or max is 0), there is no limit.
+func MaxAllocation(max uint64) Option {
+	return func(rd *reader) {
+		rd.limits.maxAllocation = max
+	}
+}
+
 type logger interface {
 	Printf(format string, vals ...interface{})
 }
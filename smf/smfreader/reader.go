@@ -1,7 +1,6 @@
 package smfreader
 
 import (
-	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -14,6 +13,7 @@ import (
 	"github.com/gomidi/midi/internal/midilib"
 	"github.com/gomidi/midi/midimessage/channel"
 	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
 	"github.com/gomidi/midi/smf"
 )
 
@@ -48,10 +48,11 @@ func ReadFile(file string, callback func(smf.Reader), options ...Option) error {
 	return nil
 }
 
-// New returns a smf.Reader
+// New returns a smf.Reader.
+// The returned reader also implements midi.EventReader for reading without allocations.
 func New(src io.Reader, opts ...Option) smf.Reader {
 	rd := &reader{
-		input: src,
+		input: &midilib.Counter{Reader: src},
 		// state:           stateExpectHeader,
 		processedTracks: -1,
 		runningStatus:   runningstatus.NewSMFReader(),
@@ -73,7 +74,7 @@ func New(src io.Reader, opts ...Option) smf.Reader {
 
 // Close closes the internal reader if it is an io.ReadCloser
 func (r *reader) Close() error {
-	if cl, is := r.input.(io.ReadCloser); is {
+	if cl, is := r.input.Reader.(io.ReadCloser); is {
 		return cl.Close()
 	}
 	return nil
@@ -83,13 +84,13 @@ func (r *reader) ReadHeader() error {
 	if r.headerIsRead {
 		return r.error
 	}
-	r.error = r.readMThd()
+	r.error = r.newError(r.readMThd())
 	r.headerIsRead = true
 	return r.error
 }
 
 type reader struct {
-	input  io.Reader
+	input  *midilib.Counter
 	logger logger
 
 	// state           state
@@ -101,14 +102,22 @@ type reader struct {
 	deltatime           uint32
 	header              smf.Header
 
+	// position of the current event, used for errors
+	eventOffset int64
+	absTicks    uint64
+	status      byte
+
 	sysexreader   *sysexReader
 	channelReader channel.Reader
 
+	limits limits
+
 	// options
 	failOnUnknownChunks bool
 	headerIsRead        bool
 	// headerError         error
 	readNoteOffPedantic bool
+	unescape            bool
 
 	error error
 }
@@ -147,8 +156,21 @@ func (r *reader) Read() (m midi.Message, err error) {
 }
 
 func (r *reader) read() (m midi.Message, err error) {
+	err = r.prepare()
+
+	if err != nil {
+		return nil, err
+	}
+
+	m, r.error = r.readEvent()
+	r.error = r.newError(r.error)
+	return m, r.error
+}
+
+// prepare reads the header and the chunk header, if needed, so that the next event can be read
+func (r *reader) prepare() error {
 	if r.isDone {
-		return nil, smf.ErrFinished
+		return smf.ErrFinished
 	}
 
 	if !r.headerIsRead {
@@ -156,7 +178,7 @@ func (r *reader) read() (m midi.Message, err error) {
 	}
 
 	if r.error != nil {
-		return nil, r.error
+		return r.error
 	}
 
 	if r.expectChunk {
@@ -164,13 +186,33 @@ func (r *reader) read() (m midi.Message, err error) {
 	}
 
 	if r.error != nil {
-		return nil, r.error
+		return r.error
 	}
 
 	// now we are inside a track
 	r.deltatime = 0
-	m, r.error = r.readEvent()
-	return m, r.error
+	return nil
+}
+
+// newError wraps the given error inside a *midi.ReadError that carries the position of the current event.
+// io.EOF and the errors signaling the end of reading are returned unchanged.
+func (r *reader) newError(err error) error {
+	switch err {
+	case nil, io.EOF, smf.ErrFinished, ErrMissing:
+		return err
+	}
+
+	if _, is := err.(*midi.ReadError); is {
+		return err
+	}
+
+	return &midi.ReadError{
+		Offset:   r.eventOffset,
+		Track:    r.processedTracks,
+		AbsTicks: r.absTicks,
+		Status:   r.status,
+		Err:      err,
+	}
 }
 
 func (r *reader) log(format string, vals ...interface{}) {
@@ -183,6 +225,7 @@ func (r *reader) readMThd() (err error) {
 
 	// after the header a chunk should come
 	r.expectChunk = true
+	r.eventOffset = r.input.N
 
 	var chunk smf.Chunk
 
@@ -218,8 +261,12 @@ func (r *reader) readChunk() {
 		chunk smf.Chunk
 	)
 
+	r.eventOffset = r.input.N
+	r.status = 0
+
 	r.expectedChunkLength, r.error = chunk.ReadHeader(r.input)
 	r.log("reading header of chunk: %v", r.error)
+	r.error = r.newError(r.error)
 
 	if r.error != nil {
 		// if we are here, not all tracks have been read, so io.EOF would be an error,
@@ -227,11 +274,19 @@ func (r *reader) readChunk() {
 		return
 	}
 
+	r.error = r.newError(r.limits.chunk(r.expectedChunkLength))
+
+	if r.error != nil {
+		return
+	}
+
 	r.log("got chunk type: %v", chunk.Type())
 	// We have a MTrk
 	if chunk.Type() == "MTrk" {
 		r.log("is track chunk")
 		r.processedTracks++
+		r.absTicks = 0
+		r.limits.events = 0
 		r.expectChunk = false
 		//p.state = stateExpectTrackEvent
 		// we are done, lets go to the track events
@@ -248,6 +303,7 @@ func (r *reader) readChunk() {
 	// The header is of an unknown type, skip over it.
 	_, r.error = io.CopyN(ioutil.Discard, r.input, int64(r.expectedChunkLength))
 	r.log("skipping chunk: %v", r.error)
+	r.error = r.newError(r.error)
 	if r.error != nil {
 		return
 	}
@@ -261,6 +317,11 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 	status, changed := r.runningStatus.Read(canary)
 	r.log("got status: % X, changed: %v", status, changed)
 
+	r.status = status
+	if status == 0 {
+		r.status = canary
+	}
+
 	// a non-channel message has reset the status
 	if status == 0 {
 
@@ -269,7 +330,19 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 		// both 0xF0 and 0xF7 may start a sysex in SMF files
 		case 0xF0, 0xF7:
 			r.log("found sysex")
-			return r.sysexreader.Read(canary, r.input)
+			var src io.Reader
+			src, err = r.payloadReader()
+			if err != nil {
+				return nil, err
+			}
+			m, err = r.sysexreader.Read(canary, src)
+
+			if esc, is := m.(sysex.Escape); is && r.unescape {
+				if msg := unescape(esc.Data()); msg != nil {
+					return msg, err
+				}
+			}
+			return m, err
 
 		// meta event
 		case 0xFF:
@@ -278,15 +351,22 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 			r.log("read system common type: % X, err: %v", typ, err)
 
 			if err != nil {
-				return nil, nil
+				return nil, err
+			}
+
+			var src io.Reader
+			src, err = r.payloadReader()
+			if err != nil {
+				return nil, err
 			}
 
 			// since System Common messages are not allowed within smf files, there could only be meta messages
 			// all (event unknown) meta messages must be handled by the meta dispatcher
-			m, err = meta.NewReader(r.input, typ).Read()
+			m, err = meta.NewReader(src, typ).Read()
 			r.log("got meta: %T", m)
 		default:
-			panic(fmt.Sprintf("must not happen: invalid canary % X", canary))
+			// data byte without running status or a message that is not allowed within SMF
+			return nil, errInvalidStatus
 		}
 
 		// on a voice/channel category message with status either given or cached (running status)
@@ -310,63 +390,84 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 		return nil, err
 	}
 
+	// should not happen: unknown events are handled inside meta.Reader
 	if m == nil {
-		panic("must not happen: unknown event should be handled inside meta.Reader")
+		return nil, errUnknownEvent
 	}
 
 	if m == meta.EndOfTrack {
-		r.log("got end of track")
-		// p.absTrackTime = 0
-		//p.deltatime = 0
-		// Expect the next chunk midi.
-		/*
-			if p.processedTracks > -1 && uint16(p.processedTracks) == p.header.NumTracks {
-				p.log("last track has been read")
-				p.isDone = true
-				return nil, ErrFinished
-			}
-		*/
-
-		// TODO check the read length of the track against the length thas has been read
-		// return ErrTruncatedTrack if meta.EndOfTrack comes to early or ErrOverflowingTrack it it comes too late
-		if uint16(r.processedTracks+1) == r.header.NumTracks {
-			r.log("last track has been read")
-			r.isDone = true
-		} else {
-			r.expectChunk = true
-		}
-
-		// p.state = stateExpectChunk
+		r.endOfTrack()
 	}
 
 	return m, nil
 }
 
+// endOfTrack prepares the reader for the next track after an end of track message
+func (r *reader) endOfTrack() {
+	r.log("got end of track")
+
+	// TODO check the read length of the track against the length thas has been read
+	// return ErrTruncatedTrack if meta.EndOfTrack comes to early or ErrOverflowingTrack it it comes too late
+	if uint16(r.processedTracks+1) == r.header.NumTracks {
+		r.log("last track has been read")
+		r.isDone = true
+		return
+	}
+
+	r.expectChunk = true
+}
+
 func (r *reader) readEvent() (m midi.Message, err error) {
 	if r.error != nil {
 		return nil, r.error
 	}
 
+	var canary byte
+	canary, err = r.readDelta()
+
+	if err == nil {
+		m, err = r._readEvent(canary)
+	}
+
+	// the event has been started with the delta, so the end of file comes unexpected
+	if err == io.EOF {
+		err = midi.ErrUnexpectedEOF
+	}
+
+	return
+}
+
+// readDelta reads the delta time of the next event and returns its canary
+func (r *reader) readDelta() (canary byte, err error) {
 	var deltatime uint32
 
+	r.eventOffset = r.input.N
+	r.status = 0
+
 	deltatime, err = midilib.ReadVarLength(r.input)
-	r.log("read delta: %v, err: %v", deltatime, err)
+	if r.logger != nil {
+		r.log("read delta: %v, err: %v", deltatime, err)
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = r.limits.event()
 	if err != nil {
 		return
 	}
 
 	r.deltatime = deltatime
+	r.absTicks += uint64(deltatime)
 
 	// read the canary in the coal mine to see, if we have a running status byte or a given one
-	var canary byte
 	canary, err = midilib.ReadByte(r.input)
-	r.log("read canary: %v, err: %v", canary, err)
-
-	if err != nil {
-		return
+	if r.logger != nil {
+		r.log("read canary: %v, err: %v", canary, err)
 	}
 
-	return r._readEvent(canary)
+	return
 }
 
 // parseHeaderData parses SMF-header chunk header data.
@@ -395,6 +496,12 @@ func (r *reader) parseHeaderData(reader io.Reader) error {
 		return err
 	}
 
+	err = r.limits.tracks(r.header.NumTracks)
+
+	if err != nil {
+		return err
+	}
+
 	var division uint16
 	division, err = midilib.ReadUint16(reader)
 
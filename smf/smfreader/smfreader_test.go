@@ -9,6 +9,7 @@ import (
 	"github.com/gomidi/midi/midimessage/channel"
 	"github.com/gomidi/midi/midimessage/meta"
 	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
 	"github.com/gomidi/midi/midimessage/sysex"
 	"github.com/gomidi/midi/smf/smfwriter"
 
@@ -272,3 +273,132 @@ func TestX(t *testing.T) {
 	_ = msg
 	// fmt.Printf("%s\n", msg)
 }
+
+func TestReadError(t *testing.T) {
+	src := []byte{
+		0x4D, 0x54, 0x68, 0x64, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x01, 0x00, 0x60, // header
+		0x4D, 0x54, 0x72, 0x6B, 0x00, 0x00, 0x00, 0x0E, // track
+		0x00, 0x90, 0x32, 0x21, // noteon
+		0x60, 0x32, 0x00, // noteoff (running status)
+		0x10, 0xFF, 0x51, 0x02, 0x07, 0xA1, // tempo with wrong length
+		0x00, 0xFF, 0x2F, 0x00,
+	}
+
+	rd := New(bytes.NewReader(src))
+
+	var err error
+
+	for err == nil {
+		_, err = rd.Read()
+	}
+
+	rerr, is := err.(*midi.ReadError)
+
+	if !is {
+		t.Fatalf("expected *midi.ReadError, got: %#v", err)
+	}
+
+	if got, want := rerr.Offset, int64(29); got != want {
+		t.Errorf("Offset = %v; want %v", got, want)
+	}
+
+	if got, want := rerr.Track, int16(0); got != want {
+		t.Errorf("Track = %v; want %v", got, want)
+	}
+
+	if got, want := rerr.AbsTicks, uint64(0x70); got != want {
+		t.Errorf("AbsTicks = %v; want %v", got, want)
+	}
+
+	if got, want := rerr.Status, byte(0xFF); got != want {
+		t.Errorf("Status = % X; want % X", got, want)
+	}
+
+	// further reading returns the same error
+	if _, err2 := rd.Read(); err2 != err {
+		t.Errorf("expected same error, got: %v", err2)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	header := []byte{0x4D, 0x54, 0x68, 0x64, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x01, 0x00, 0x60}
+
+	tests := []struct {
+		descr string
+		track []byte
+	}{
+		{"empty sysex", []byte{0x00, 0xF0, 0x00}},
+		{"data byte without running status", []byte{0x00, 0x40, 0x40}},
+		{"system common message", []byte{0x00, 0xF2, 0x00, 0x00}},
+		{"realtime message", []byte{0x00, 0xF8}},
+		{"truncated meta type", []byte{0x00, 0xFF}},
+		{"overlong delta", []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x7F, 0xFF, 0x2F, 0x00}},
+	}
+
+	for _, test := range tests {
+		var src []byte
+		src = append(src, header...)
+		src = append(src, 0x4D, 0x54, 0x72, 0x6B, 0x00, 0x00, 0x00, byte(len(test.track)))
+		src = append(src, test.track...)
+
+		rd := New(bytes.NewReader(src))
+
+		_, err := rd.Read()
+
+		if _, is := err.(*midi.ReadError); !is {
+			t.Errorf("[%s] expected *midi.ReadError, got: %#v", test.descr, err)
+		}
+	}
+}
+
+func TestUnescapeRealtimeSysCommon(t *testing.T) {
+	var bf bytes.Buffer
+
+	wr := smfwriter.New(&bf, smfwriter.EscapeRealtimeSysCommon())
+	wr.Write(realtime.TimingClock)
+	wr.SetDelta(2)
+	wr.Write(syscommon.SPP(300))
+	wr.Write(syscommon.MTC(3))
+	wr.Write(syscommon.Tune)
+	wr.Write(sysex.Escape([]byte{0xF3, 0x01, 0x02}))
+	wr.Write(meta.EndOfTrack)
+
+	var res bytes.Buffer
+	res.WriteString("\n")
+
+	rd := New(bytes.NewReader(bf.Bytes()), UnescapeRealtimeSysCommon())
+	evrd := New(bytes.NewReader(bf.Bytes()), UnescapeRealtimeSysCommon()).(midi.EventReader)
+
+	var ev midi.Event
+
+	for {
+		m, err := rd.Read()
+
+		if err != nil {
+			break
+		}
+
+		if err := evrd.ReadEvent(&ev); err != nil {
+			t.Fatalf("ReadEvent returned error %v", err)
+		}
+
+		if got, expected := ev.AppendRaw(nil), m.Raw(); !bytes.Equal(got, expected) {
+			t.Errorf("ReadEvent got % X, Read got % X (%s)", got, expected, m)
+		}
+
+		fmt.Fprintf(&res, "[%v] %s\n", rd.Delta(), m)
+	}
+
+	expected := `
+[0] TimingClock
+[2] syscommon.SPP: 300
+[0] syscommon.MTC: 3
+[0] syscommon.Tune
+[0] sysex.Escape len: 3
+[0] meta.EndOfTrack
+`
+
+	if got := res.String(); got != expected {
+		t.Errorf("got\n%v\n\nwant\n%v\n\n", got, expected)
+	}
+}
@@ -35,6 +35,11 @@ func (s *sysexReader) Read(startcode byte, rd io.Reader) (sys sysex.Message, err
 			return nil, err
 		}
 
+		// even a complete sysex must have at least the terminating F7
+		if len(data) == 0 {
+			return nil, errEmptySysEx
+		}
+
 		// complete sysex
 		if data[len(data)-1] == 0xF7 {
 			s.inSequence = false
@@ -54,7 +59,7 @@ func (s *sysexReader) Read(startcode byte, rd io.Reader) (sys sysex.Message, err
 		}
 
 		// End of sysex sequence
-		if data[len(data)-1] == 0xF7 {
+		if len(data) > 0 && data[len(data)-1] == 0xF7 {
 			// casio style
 			if s.inSequence {
 				s.inSequence = false
@@ -70,7 +75,7 @@ func (s *sysexReader) Read(startcode byte, rd io.Reader) (sys sysex.Message, err
 		return sysex.Escape(data), nil
 
 	default:
-		panic("sysex in SMF must start with F0 or F7")
+		return nil, errInvalidSysExStart
 	}
 
 }
@@ -0,0 +1,90 @@
+package smfreader
+
+import (
+	"bytes"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
+)
+
+var realtimeMessages = map[byte]realtime.Message{
+	0xF8: realtime.TimingClock,
+	0xF9: realtime.Tick,
+	0xFA: realtime.Start,
+	0xFB: realtime.Continue,
+	0xFC: realtime.Stop,
+	0xFD: realtime.Undefined4,
+	0xFE: realtime.Activesense,
+	0xFF: realtime.Reset,
+}
+
+// isRealtimeSysCommon checks, if data is exactly one realtime or system common message
+func isRealtimeSysCommon(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	for _, b := range data[1:] {
+		if b > 0x7F {
+			return false
+		}
+	}
+
+	switch data[0] {
+	case 0xF1, 0xF3:
+		return len(data) == 2
+	case 0xF2:
+		return len(data) == 3
+	case 0xF6:
+		return len(data) == 1
+	default:
+		return data[0] >= 0xF8 && len(data) == 1
+	}
+}
+
+// unescape returns the realtime or system common message inside the data of a sysex escape.
+// It returns nil, if data is not a single realtime or system common message.
+func unescape(data []byte) midi.Message {
+	if !isRealtimeSysCommon(data) {
+		return nil
+	}
+
+	if data[0] >= 0xF8 {
+		return realtimeMessages[data[0]]
+	}
+
+	m, err := syscommon.NewReader(bytes.NewReader(data[1:]), data[0]).Read()
+
+	if err != nil || m == nil {
+		return nil
+	}
+
+	return m
+}
+
+// unescapeEvent is the counterpart of unescape for events
+func unescapeEvent(ev *midi.Event) {
+	data := ev.Payload
+
+	if !isRealtimeSysCommon(data) {
+		return
+	}
+
+	ev.Kind = midi.KindSysCommon
+	if data[0] >= 0xF8 {
+		ev.Kind = midi.KindRealtime
+	}
+
+	ev.Status = data[0]
+
+	if len(data) > 1 {
+		ev.Data1 = data[1]
+	}
+
+	if len(data) > 2 {
+		ev.Data2 = data[2]
+	}
+
+	ev.Payload = data[:0]
+}
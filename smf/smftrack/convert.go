@@ -0,0 +1,161 @@
+package smftrack
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+var (
+	// ErrSMF2 is returned when converting a SMF2 file, since its tracks are independent sequences
+	ErrSMF2 = errors.New("SMF2 (sequential tracks) can't be converted")
+)
+
+// ToSMF0 returns a copy of the SMF with all tracks merged into a single track.
+// Simultaneous events are ordered by the number of their track and keep their order within the track.
+func (s *SMF) ToSMF0() (*SMF, error) {
+	if s.Format == smf.SMF2 {
+		return nil, ErrSMF2
+	}
+
+	var merged Track
+	var num int
+
+	for _, t := range s.Tracks {
+		num += len(t.Events)
+		if end := t.EndTicks(); end > merged.End {
+			merged.End = end
+		}
+	}
+
+	merged.Events = make([]Event, 0, num)
+
+	for _, t := range s.Tracks {
+		merged.Events = append(merged.Events, t.Events...)
+	}
+
+	// since the events of each track are already ordered, a stable sort keeps the order of tracks and events
+	sort.SliceStable(merged.Events, func(a, b int) bool {
+		return merged.Events[a].AbsTicks < merged.Events[b].AbsTicks
+	})
+
+	return &SMF{Format: smf.SMF0, TimeFormat: s.TimeFormat, Tracks: []*Track{&merged}}, nil
+}
+
+// ToSMF1 returns a copy of the SMF with the events split into a conductor track (the first track)
+// and a track for each MIDI channel that is used (ordered by the channel).
+//
+// The conductor track gets all meta messages that are relevant for every track (tempo, time signature, key signature,
+// markers, cue points, SMPTE offset, sequence number and copyright) as well as all other messages that are not
+// related to a channel.
+// Meta messages and sysex messages that follow a meta.Channel prefix (e.g. track names and program names)
+// belong to the track of that channel until the next channel message, as does the prefix itself.
+// Track names, instrument names and program names without a prefix belong to the track of the channel of
+// the following channel message (or prefix). If there is none, they belong to the conductor track.
+//
+// If the SMF has multiple tracks, they are merged first (see ToSMF0).
+func (s *SMF) ToSMF1() (*SMF, error) {
+	if s.Format == smf.SMF2 {
+		return nil, ErrSMF2
+	}
+
+	src := s
+
+	if len(s.Tracks) > 1 {
+		var err error
+		src, err = s.ToSMF0()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		conductor = &Track{}
+		channels  [16]*Track
+		prefix    = -1
+		end       uint64
+	)
+
+	if len(src.Tracks) > 0 {
+		end = src.Tracks[0].EndTicks()
+		conductor.End = end
+	}
+
+	track := func(ch uint8) *Track {
+		if channels[ch] == nil {
+			channels[ch] = &Track{End: end}
+		}
+		return channels[ch]
+	}
+
+	var events []Event
+	if len(src.Tracks) > 0 {
+		events = src.Tracks[0].Events
+	}
+
+	// names without a prefix, waiting for the following channel
+	var pending []Event
+
+	for _, ev := range events {
+		t := conductor
+
+		switch v := ev.Message.(type) {
+		case channel.Message:
+			t = track(v.Channel())
+			prefix = -1
+		case meta.Channel:
+			prefix = int(uint8(v) & 0x0F)
+			t = track(uint8(prefix))
+		case meta.Sequence, meta.Track, meta.Program:
+			if prefix < 0 {
+				pending = append(pending, ev)
+				continue
+			}
+			t = track(uint8(prefix))
+		default:
+			if prefix >= 0 && !isConductorMessage(v) {
+				t = track(uint8(prefix))
+			}
+		}
+
+		if t != conductor {
+			t.Events = append(t.Events, pending...)
+			pending = nil
+		}
+
+		t.Events = append(t.Events, ev)
+	}
+
+	if len(pending) > 0 {
+		conductor.Events = append(conductor.Events, pending...)
+
+		// the names come after the conductor messages at the same position
+		sort.SliceStable(conductor.Events, func(a, b int) bool {
+			return conductor.Events[a].AbsTicks < conductor.Events[b].AbsTicks
+		})
+	}
+
+	res := &SMF{Format: smf.SMF1, TimeFormat: s.TimeFormat, Tracks: []*Track{conductor}}
+
+	for _, t := range channels {
+		if t != nil {
+			res.Tracks = append(res.Tracks, t)
+		}
+	}
+
+	return res, nil
+}
+
+// isConductorMessage returns true for the meta messages that are relevant for all tracks
+func isConductorMessage(m midi.Message) bool {
+	switch m.(type) {
+	case meta.Tempo, meta.TimeSig, meta.Key, meta.Marker, meta.Cuepoint, meta.SMPTE, meta.SequenceNo, meta.Copyright:
+		return true
+	default:
+		return false
+	}
+}
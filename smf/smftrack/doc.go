@@ -0,0 +1,33 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package smftrack provides an in-memory representation of Standard MIDI Files (SMF) for modification.
+
+The messages of each track are kept as events with absolute positions in ticks,
+so that tracks can be modified and converted independent of the delta times.
+
+Usage
+
+	import (
+		"github.com/gomidi/midi/smf/smftrack"
+	)
+
+	s, err := smftrack.ReadFile("song.mid")
+
+	if err != nil {
+		// handle error
+	}
+
+	// convert a multitrack file to a single track file
+	s0, err := s.ToSMF0()
+
+	if err != nil {
+		// handle error
+	}
+
+	err = s0.WriteFile("song0.mid")
+
+*/
+package smftrack
@@ -0,0 +1,196 @@
+package smftrack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+// Groove is a groove template: the timing and velocity offsets of the positions of a grid.
+// The grid starts at position 0 and repeats every Steps positions (e.g. 16 steps of sixteenth notes for a 4/4 bar).
+// The offsets are independent of the resolution.
+type Groove struct {
+	// NoteValue is the note value of the grid (e.g. 16 for sixteenth notes)
+	NoteValue uint8 `json:"note_value"`
+
+	// Timing are the offsets of the steps as fractions of the distance between two grid positions
+	Timing []float64 `json:"timing"`
+
+	// Velocity are the offsets of the velocities of the notes on the steps
+	Velocity []float64 `json:"velocity"`
+}
+
+// Steps returns the number of grid positions after which the groove repeats
+func (g *Groove) Steps() int {
+	return len(g.Timing)
+}
+
+// gridTicks returns the distance between two grid positions
+func gridTicks(timeformat smf.TimeFormat, noteValue uint8) (float64, error) {
+	resolution, isMetric := timeformat.(smf.MetricTicks)
+
+	if !isMetric {
+		return 0, ErrTimeCode
+	}
+
+	if noteValue == 0 {
+		return 0, fmt.Errorf("invalid note value 0")
+	}
+
+	grid := float64(resolution.Ticks4th()) * 4 / float64(noteValue)
+
+	if grid < 1 {
+		return 0, fmt.Errorf("grid of 1/%v notes is too fine for resolution %v", noteValue, resolution)
+	}
+
+	return grid, nil
+}
+
+// ExtractGroove extracts a groove template with the given grid from the notes of all tracks.
+// The timing offset of a step is the average distance of the notes to the grid position.
+// The velocity offset of a step is the difference between the average velocity of its notes and
+// the average velocity of all notes. Steps without notes have no offsets.
+func (s *SMF) ExtractGroove(noteValue uint8, steps int) (*Groove, error) {
+	grid, err := gridTicks(s.TimeFormat, noteValue)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if steps < 1 {
+		return nil, fmt.Errorf("invalid number of steps %v", steps)
+	}
+
+	g := &Groove{NoteValue: noteValue, Timing: make([]float64, steps), Velocity: make([]float64, steps)}
+	counts := make([]float64, steps)
+	var total, velocities float64
+
+	for _, t := range s.Tracks {
+		for _, n := range t.notes() {
+			k := math.Round(float64(n.start) / grid)
+			step := int(math.Mod(k, float64(steps)))
+			vel := float64(t.Events[n.on].Message.(channel.NoteOn).Velocity())
+
+			g.Timing[step] += (float64(n.start) - k*grid) / grid
+			g.Velocity[step] += vel
+			counts[step]++
+			velocities += vel
+			total++
+		}
+	}
+
+	for i := range counts {
+		if counts[i] > 0 {
+			g.Timing[i] /= counts[i]
+			g.Velocity[i] = g.Velocity[i]/counts[i] - velocities/total
+		}
+	}
+
+	return g, nil
+}
+
+// Save writes the groove template as JSON
+func (g *Groove) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
+
+// LoadGroove reads a groove template that has been written by Groove.Save
+func LoadGroove(r io.Reader) (*Groove, error) {
+	var g Groove
+
+	if err := json.NewDecoder(r).Decode(&g); err != nil {
+		return nil, err
+	}
+
+	if g.Steps() == 0 || len(g.Velocity) != g.Steps() {
+		return nil, fmt.Errorf("invalid groove: %v timing and %v velocity steps", len(g.Timing), len(g.Velocity))
+	}
+
+	return &g, nil
+}
+
+// ApplyGroove returns a copy of the SMF with the groove template applied to the notes of all tracks.
+// Each note is moved from its position towards the groove position of the nearest grid position and its
+// velocity is changed by the velocity offset. Strength (0-100) scales both in percent.
+// The notes keep their durations, other messages are handled as with Quantize.
+func (s *SMF) ApplyGroove(g *Groove, strength uint8) (*SMF, error) {
+	grid, err := gridTicks(s.TimeFormat, g.NoteValue)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if g.Steps() == 0 || len(g.Velocity) != g.Steps() {
+		return nil, fmt.Errorf("invalid groove: %v timing and %v velocity steps", len(g.Timing), len(g.Velocity))
+	}
+
+	factor := clampPercent(strength) / 100
+	steps := float64(g.Steps())
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	for _, t := range s.Tracks {
+		res.Tracks = append(res.Tracks, t.changeNotes(func(n note, vel uint8) (start uint64, velocity uint8) {
+			k := math.Round(float64(n.start) / grid)
+			step := int(math.Mod(k, steps))
+			target := (k + g.Timing[step]) * grid
+			start = uint64(math.Max(0, math.Round(float64(n.start)+(target-float64(n.start))*factor)))
+			return start, clampVelocity(float64(vel) + g.Velocity[step]*factor)
+		}))
+	}
+
+	return res, nil
+}
+
+// Humanize returns a copy of the SMF with the notes of all tracks moved by a random amount of up to the given ticks
+// and their velocities changed by a random amount of up to the given velocity (in both directions).
+// The notes keep their durations, other messages are handled as with Quantize.
+// The same seed gives the same result.
+func (s *SMF) Humanize(seed int64, ticks uint32, velocity uint8) *SMF {
+	rnd := rand.New(rand.NewSource(seed))
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	random := func(max float64) float64 {
+		return math.Round((rnd.Float64()*2 - 1) * max)
+	}
+
+	for _, t := range s.Tracks {
+		res.Tracks = append(res.Tracks, t.changeNotes(func(n note, vel uint8) (uint64, uint8) {
+			start := math.Max(0, float64(n.start)+random(float64(ticks)))
+			return uint64(start), clampVelocity(float64(vel) + random(float64(velocity)))
+		}))
+	}
+
+	return res
+}
+
+func clampVelocity(vel float64) uint8 {
+	return uint8(math.Max(1, math.Min(127, math.Round(vel))))
+}
+
+// changeNotes returns a copy of the track with the notes moved and their velocities changed by the given function.
+// The notes keep their durations.
+func (t *Track) changeNotes(change func(n note, velocity uint8) (start uint64, newVelocity uint8)) *Track {
+	tr := &Track{End: t.End, Events: make([]Event, len(t.Events))}
+	copy(tr.Events, t.Events)
+
+	starts := map[int]uint64{}
+
+	for _, n := range t.notes() {
+		on := t.Events[n.on].Message.(channel.NoteOn)
+		start, vel := change(n, on.Velocity())
+		starts[n.on] = start
+		tr.Events[n.on].Message = channel.Channel(on.Channel()).NoteOn(on.Key(), vel)
+	}
+
+	return tr.moveNotes(func(n note) (uint64, uint64) {
+		start := starts[n.on]
+		return start, start + n.end - n.start
+	})
+}
@@ -0,0 +1,109 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+func mkVelocityNotes(notes ...uint64) *SMF {
+	var tr Track
+	ch := channel.Channel0
+
+	// position and velocity of each note, the notes are 10 ticks long
+	for i := 0; i < len(notes); i += 2 {
+		tr.Add(notes[i], ch.NoteOn(60, uint8(notes[i+1])))
+		tr.Add(notes[i]+10, ch.NoteOff(60))
+	}
+
+	return &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+}
+
+// noteVelocities returns the start, end and velocity of each note of the first track
+func noteVelocities(s *SMF) string {
+	var bf bytes.Buffer
+	t := s.Tracks[0]
+	for _, n := range t.notes() {
+		fmt.Fprintf(&bf, "%v-%v:%v ", n.start, n.end, t.Events[n.on].Message.(channel.NoteOn).Velocity())
+	}
+	return bf.String()
+}
+
+func TestGroove(t *testing.T) {
+	reference := mkVelocityNotes(0, 100, 30, 80, 48, 100, 78, 60)
+	g, err := reference.ExtractGroove(16, 2)
+
+	if err != nil {
+		t.Fatalf("can't extract groove: %v", err)
+	}
+
+	if got, expected := fmt.Sprint(g.Timing, g.Velocity), "[0 0.25] [15 -15]"; got != expected {
+		t.Errorf("extracted %s; want %s", got, expected)
+	}
+
+	var bf bytes.Buffer
+
+	if err := g.Save(&bf); err != nil {
+		t.Fatalf("can't save groove: %v", err)
+	}
+
+	g, err = LoadGroove(&bf)
+
+	if err != nil {
+		t.Fatalf("can't load groove: %v", err)
+	}
+
+	tests := []struct {
+		strength uint8
+		expected string
+	}{
+		{100, "0-10:105 30-40:75 48-58:105 78-88:75 "},
+		{50, "0-10:98 27-37:83 48-58:98 75-85:83 "},
+		{0, "0-10:90 24-34:90 48-58:90 72-82:90 "},
+	}
+
+	for _, test := range tests {
+		res, err := mkVelocityNotes(0, 90, 24, 90, 48, 90, 72, 90).ApplyGroove(g, test.strength)
+
+		if err != nil {
+			t.Fatalf("can't apply groove: %v", err)
+		}
+
+		if got := noteVelocities(res); got != test.expected {
+			t.Errorf("ApplyGroove(%v) = %q; want %q", test.strength, got, test.expected)
+		}
+	}
+
+	if _, err := LoadGroove(bytes.NewReader([]byte(`{"note_value": 16, "timing": [0], "velocity": []}`))); err == nil {
+		t.Errorf("expected error for invalid groove")
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	s := mkVelocityNotes(0, 1, 100, 64, 200, 127, 300, 64)
+
+	a := noteVelocities(s.Humanize(42, 5, 10))
+	b := noteVelocities(s.Humanize(42, 5, 10))
+
+	if a != b {
+		t.Errorf("same seed gives different results: %q and %q", a, b)
+	}
+
+	if a == noteVelocities(s) {
+		t.Errorf("nothing has been changed")
+	}
+
+	h := s.Humanize(7, 5, 10).Tracks[0]
+
+	for i, n := range h.notes() {
+		orig := uint64(i * 100)
+		vel := h.Events[n.on].Message.(channel.NoteOn).Velocity()
+
+		if n.start+5 < orig || n.start > orig+5 || n.end-n.start != 10 || vel < 1 || vel > 127 {
+			t.Errorf("note %v out of range: %v-%v:%v", i, n.start, n.end, vel)
+		}
+	}
+}
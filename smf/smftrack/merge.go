@@ -0,0 +1,279 @@
+package smftrack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// ConflictPolicy decides how tempo and time signature messages of layered files are handled
+type ConflictPolicy uint8
+
+const (
+	// KeepFirst keeps the tempo and time signature messages of the first file that has any and removes them from the others
+	KeepFirst ConflictPolicy = iota
+
+	// KeepLast keeps the tempo and time signature messages of the last file that has any and removes them from the others
+	KeepLast
+
+	// KeepAll keeps the tempo and time signature messages of all files
+	KeepAll
+
+	// FailOnConflict returns ErrConflict if the files have different tempo and time signature messages.
+	// Otherwise they are kept only for the first file (like KeepFirst).
+	FailOnConflict
+)
+
+var (
+	// ErrConflict is returned by Layer with the FailOnConflict policy, if the tempo and time signature messages of the files differ
+	ErrConflict = errors.New("files have different tempo or time signature messages")
+)
+
+// Concat returns a file that plays the given files one after another.
+// The tracks are concatenated by their index, i.e. the first track of the result consists of the first tracks of all files etc.
+// Each file starts at the end of the longest track of the previous file.
+//
+// If the time format is smf.MetricTicks, the positions are converted to the resolution of the first file.
+// Since a file without tempo or time signature messages at its start would otherwise inherit the tempo and time signature
+// of the previous file, the default tempo (120 BPM) and time signature (4/4) are added to the first track in this case.
+func Concat(files ...*SMF) (*SMF, error) {
+	files, err := sameTimeFormat(files)
+
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	res := &SMF{Format: smf.SMF0, TimeFormat: files[0].TimeFormat}
+	var offset uint64
+
+	for i, f := range files {
+		if f.Format == smf.SMF2 {
+			return nil, ErrSMF2
+		}
+
+		for len(res.Tracks) < len(f.Tracks) {
+			res.Tracks = append(res.Tracks, &Track{})
+		}
+
+		var end uint64
+
+		for j, t := range f.Tracks {
+			var evts []Event
+
+			// make the defaults explicit, see above
+			if i > 0 && j == 0 {
+				if !startsWith(t, isTempo) {
+					evts = append(evts, Event{AbsTicks: offset, Message: meta.BPM(120)})
+				}
+				if !startsWith(t, isTimeSig) {
+					evts = append(evts, Event{AbsTicks: offset, Message: meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}})
+				}
+			}
+
+			for _, ev := range t.Events {
+				ev.AbsTicks += offset
+				evts = append(evts, ev)
+			}
+
+			res.Tracks[j].Events = append(res.Tracks[j].Events, evts...)
+
+			if e := t.EndTicks(); e > end {
+				end = e
+			}
+		}
+
+		offset += end
+	}
+
+	for _, t := range res.Tracks {
+		t.End = offset
+	}
+
+	if len(res.Tracks) > 1 {
+		res.Format = smf.SMF1
+	}
+
+	return res, nil
+}
+
+// Layer returns a multi track (SMF1) file that plays the given files in parallel by having all of their tracks.
+// The tempo and time signature messages of the files are handled according to the given policy.
+// Except for KeepAll, the kept tempo and time signature messages are moved to the first (conductor) track.
+// If the time format is smf.MetricTicks, the positions are converted to the resolution of the first file.
+func Layer(policy ConflictPolicy, files ...*SMF) (*SMF, error) {
+	files, err := sameTimeFormat(files)
+
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	// the index of the file whose tempo and time signature messages are kept (-1 for all)
+	keep := -1
+
+	switch policy {
+	case KeepFirst, FailOnConflict:
+		for i := len(files) - 1; i >= 0; i-- {
+			if hasConductorMessages(files[i]) {
+				keep = i
+			}
+		}
+	case KeepLast:
+		for i := range files {
+			if hasConductorMessages(files[i]) {
+				keep = i
+			}
+		}
+	}
+
+	if policy == FailOnConflict {
+		for _, f := range files {
+			if hasConductorMessages(f) && !bytes.Equal(conductorMessages(f), conductorMessages(files[keep])) {
+				return nil, ErrConflict
+			}
+		}
+	}
+
+	res := &SMF{Format: smf.SMF1, TimeFormat: files[0].TimeFormat}
+
+	// the kept tempo and time signature messages that have to be moved to the conductor track
+	var conductor []Event
+
+	for i, f := range files {
+		if f.Format == smf.SMF2 {
+			return nil, ErrSMF2
+		}
+
+		for _, t := range f.Tracks {
+			nt := &Track{End: t.End}
+
+			for _, ev := range t.Events {
+				if keep >= 0 && (isTempo(ev.Message) || isTimeSig(ev.Message)) {
+					if i != keep {
+						continue
+					}
+
+					if len(res.Tracks) > 0 {
+						conductor = append(conductor, ev)
+						continue
+					}
+				}
+				nt.Events = append(nt.Events, ev)
+			}
+
+			res.Tracks = append(res.Tracks, nt)
+		}
+	}
+
+	if len(conductor) > 0 {
+		first := res.Tracks[0]
+		first.Events = append(conductor, first.Events...)
+
+		// the moved messages come first at the same position
+		sort.SliceStable(first.Events, func(a, b int) bool {
+			return first.Events[a].AbsTicks < first.Events[b].AbsTicks
+		})
+	}
+
+	return res, nil
+}
+
+// sameTimeFormat returns the files with the time format of the first file
+func sameTimeFormat(files []*SMF) ([]*SMF, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	res := make([]*SMF, len(files))
+	res[0] = files[0]
+
+	for i, f := range files[1:] {
+		res[i+1] = f
+
+		target, isMetric := files[0].TimeFormat.(smf.MetricTicks)
+		src, srcIsMetric := f.TimeFormat.(smf.MetricTicks)
+
+		switch {
+		case isMetric && srcIsMetric:
+			if src.Number() != target.Number() {
+				res[i+1] = f.scale(uint64(target.Number()), uint64(src.Number()))
+			}
+		case f.TimeFormat != files[0].TimeFormat:
+			return nil, fmt.Errorf("can't combine time formats %v and %v", files[0].TimeFormat, f.TimeFormat)
+		}
+	}
+
+	return res, nil
+}
+
+// scale returns a copy of the file with the positions multiplied by num/denom (rounded)
+func (s *SMF) scale(num, denom uint64) *SMF {
+	conv := func(ticks uint64) uint64 {
+		return (ticks*num + denom/2) / denom
+	}
+
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	for _, t := range s.Tracks {
+		nt := &Track{End: conv(t.End), Events: make([]Event, len(t.Events))}
+
+		for i, ev := range t.Events {
+			ev.AbsTicks = conv(ev.AbsTicks)
+			nt.Events[i] = ev
+		}
+
+		res.Tracks = append(res.Tracks, nt)
+	}
+
+	if tf, is := s.TimeFormat.(smf.MetricTicks); is {
+		res.TimeFormat = smf.MetricTicks(conv(uint64(tf.Number())))
+	}
+
+	return res
+}
+
+func isTempo(m midi.Message) bool {
+	_, is := m.(meta.Tempo)
+	return is
+}
+
+func isTimeSig(m midi.Message) bool {
+	_, is := m.(meta.TimeSig)
+	return is
+}
+
+// startsWith returns true, if the track has a matching message at position 0
+func startsWith(t *Track, match func(midi.Message) bool) bool {
+	for _, ev := range t.Events {
+		if ev.AbsTicks > 0 {
+			return false
+		}
+		if match(ev.Message) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasConductorMessages(s *SMF) bool {
+	return len(conductorMessages(s)) > 0
+}
+
+// conductorMessages returns the positions and raw bytes of the tempo and time signature messages of all tracks
+func conductorMessages(s *SMF) []byte {
+	var bf bytes.Buffer
+
+	for _, t := range s.Tracks {
+		for _, ev := range t.Events {
+			if isTempo(ev.Message) || isTimeSig(ev.Message) {
+				fmt.Fprintf(&bf, "%v:% X\n", ev.AbsTicks, ev.Message.Raw())
+			}
+		}
+	}
+
+	return bf.Bytes()
+}
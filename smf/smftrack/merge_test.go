@@ -0,0 +1,135 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func mkSMF(resolution uint16, tempo uint32, key uint8, length uint64) *SMF {
+	var tr Track
+
+	if tempo > 0 {
+		tr.Add(0, meta.BPM(tempo))
+	}
+
+	tr.Add(0, channel.Channel0.NoteOn(key, 100))
+	tr.Add(length, channel.Channel0.NoteOff(key))
+
+	return &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(resolution), Tracks: []*Track{&tr}}
+}
+
+func TestConcat(t *testing.T) {
+	intro := mkSMF(96, 100, 60, 96)
+	loop := mkSMF(192, 0, 62, 96)
+	outro := mkSMF(96, 140, 64, 48)
+
+	s, err := Concat(intro, loop, outro)
+
+	if err != nil {
+		t.Fatalf("can't concat: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Tempo BPM: 100.00
+Track 0@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@96 channel.NoteOff channel 0 key 60
+Track 0@96 meta.Tempo BPM: 120.00
+Track 0@96 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+Track 0@96 channel.NoteOn channel 0 key 62 velocity 100
+Track 0@144 channel.NoteOff channel 0 key 62
+Track 0@144 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+Track 0@144 meta.Tempo BPM: 140.00
+Track 0@144 channel.NoteOn channel 0 key 64 velocity 100
+Track 0@192 channel.NoteOff channel 0 key 64
+Track 0@192 end
+`
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	if _, err := Concat(intro, &SMF{Format: smf.SMF0, TimeFormat: smf.SMPTE25(40)}); err == nil {
+		t.Errorf("expected error for different time formats")
+	}
+}
+
+func TestLayer(t *testing.T) {
+	tests := []struct {
+		policy   ConflictPolicy
+		expected string
+	}{
+		{
+			KeepFirst,
+			`
+SMF1 (multitrack) 96 MetricTicks
+Track 0@0 meta.Tempo BPM: 100.00
+Track 0@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@96 channel.NoteOff channel 0 key 60
+Track 0@0 end
+Track 1@0 channel.NoteOn channel 0 key 62 velocity 100
+Track 1@48 channel.NoteOff channel 0 key 62
+Track 1@0 end
+Track 2@0 channel.NoteOn channel 0 key 64 velocity 100
+Track 2@96 channel.NoteOff channel 0 key 64
+Track 2@0 end
+`,
+		},
+		{
+			KeepLast,
+			`
+SMF1 (multitrack) 96 MetricTicks
+Track 0@0 meta.Tempo BPM: 140.00
+Track 0@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@96 channel.NoteOff channel 0 key 60
+Track 0@0 end
+Track 1@0 channel.NoteOn channel 0 key 62 velocity 100
+Track 1@48 channel.NoteOff channel 0 key 62
+Track 1@0 end
+Track 2@0 channel.NoteOn channel 0 key 64 velocity 100
+Track 2@96 channel.NoteOff channel 0 key 64
+Track 2@0 end
+`,
+		},
+		{
+			KeepAll,
+			`
+SMF1 (multitrack) 96 MetricTicks
+Track 0@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@96 channel.NoteOff channel 0 key 60
+Track 0@0 end
+Track 1@0 meta.Tempo BPM: 100.00
+Track 1@0 channel.NoteOn channel 0 key 62 velocity 100
+Track 1@48 channel.NoteOff channel 0 key 62
+Track 1@0 end
+Track 2@0 meta.Tempo BPM: 140.00
+Track 2@0 channel.NoteOn channel 0 key 64 velocity 100
+Track 2@96 channel.NoteOff channel 0 key 64
+Track 2@0 end
+`,
+		},
+	}
+
+	for _, test := range tests {
+		s, err := Layer(test.policy, mkSMF(96, 0, 60, 96), mkSMF(192, 100, 62, 96), mkSMF(96, 140, 64, 96))
+
+		if err != nil {
+			t.Fatalf("[%v] can't layer: %v", test.policy, err)
+		}
+
+		if got := dump(s); got != test.expected {
+			t.Errorf("[%v] got:\n%s\nwanted:\n%s", test.policy, got, test.expected)
+		}
+	}
+
+	if _, err := Layer(FailOnConflict, mkSMF(96, 100, 60, 96), mkSMF(96, 140, 64, 96)); err != ErrConflict {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+
+	if _, err := Layer(FailOnConflict, mkSMF(96, 100, 60, 96), mkSMF(192, 100, 64, 96)); err != nil {
+		t.Errorf("expected no error for equal tempos, got %v", err)
+	}
+}
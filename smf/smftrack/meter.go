@@ -0,0 +1,37 @@
+package smftrack
+
+import (
+	"errors"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/meta/meter"
+	"github.com/gomidi/midi/smf"
+)
+
+// ErrTimeCode is returned, when bars and beats are needed for a SMF with a time code based time format
+var ErrTimeCode = errors.New("bars and beats need a metric time format")
+
+// MeterMap returns the meter.Map of the time signature messages of all tracks
+func (s *SMF) MeterMap() (*meter.Map, error) {
+	if s.Format == smf.SMF2 {
+		return nil, ErrSMF2
+	}
+
+	resolution, is := s.TimeFormat.(smf.MetricTicks)
+
+	if !is {
+		return nil, ErrTimeCode
+	}
+
+	m := meter.NewMap(resolution)
+
+	for _, t := range s.Tracks {
+		for _, ev := range t.Events {
+			if ts, is := ev.Message.(meta.TimeSig); is {
+				m.Add(ev.AbsTicks, ts)
+			}
+		}
+	}
+
+	return m, nil
+}
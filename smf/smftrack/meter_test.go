@@ -0,0 +1,32 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/meta/meter"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestMeterMap(t *testing.T) {
+	var conductor, t1 Track
+	conductor.Add(0, meter.M3_4())
+	t1.Add(576, meter.M6_8())
+
+	s := &SMF{Format: smf.SMF1, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&conductor, &t1}}
+
+	m, err := s.MeterMap()
+
+	if err != nil {
+		t.Fatalf("can't get meter map: %v", err)
+	}
+
+	if got := m.Position(576 + 288 + 48).String(); got != "4.2.0" {
+		t.Errorf("Position = %s; want 4.2.0", got)
+	}
+
+	s.TimeFormat = smf.SMPTE25(40)
+
+	if _, err := s.MeterMap(); err != ErrTimeCode {
+		t.Errorf("expected ErrTimeCode, got %v", err)
+	}
+}
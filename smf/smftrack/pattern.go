@@ -0,0 +1,151 @@
+package smftrack
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+var (
+	// ErrNotSMF2 is returned when asking for the patterns of a file that is not a SMF2 file
+	ErrNotSMF2 = errors.New("not a SMF2 (sequential tracks) file")
+)
+
+// Pattern is a track of a SMF2 file. Each pattern is an independent sequence.
+type Pattern struct {
+	// Number is the sequence number (meta.SequenceNo at the start of the track).
+	// If the track has no sequence number, it is the index of the track (as defined by the SMF specification).
+	Number uint16
+
+	// Name is the name of the sequence (meta.Sequence at the start of the track)
+	Name string
+
+	// Track holds the events of the pattern
+	Track *Track
+}
+
+// Patterns returns the patterns of a SMF2 file in the order of the tracks.
+func (s *SMF) Patterns() ([]Pattern, error) {
+	if s.Format != smf.SMF2 {
+		return nil, ErrNotSMF2
+	}
+
+	patterns := make([]Pattern, len(s.Tracks))
+
+	for i, t := range s.Tracks {
+		patterns[i] = Pattern{Number: uint16(i), Track: t}
+
+		for _, ev := range t.Events {
+			if ev.AbsTicks > 0 {
+				break
+			}
+
+			switch v := ev.Message.(type) {
+			case meta.SequenceNo:
+				patterns[i].Number = v.Number()
+			case meta.Sequence:
+				patterns[i].Name = v.Text()
+			}
+		}
+	}
+
+	return patterns, nil
+}
+
+// Pattern returns the first pattern of a SMF2 file that has the given sequence number.
+func (s *SMF) Pattern(number uint16) (p Pattern, err error) {
+	return s.findPattern(func(p Pattern) bool { return p.Number == number }, fmt.Sprintf("number %v", number))
+}
+
+// PatternByName returns the first pattern of a SMF2 file that has the given name.
+func (s *SMF) PatternByName(name string) (p Pattern, err error) {
+	return s.findPattern(func(p Pattern) bool { return p.Name == name }, fmt.Sprintf("name %#v", name))
+}
+
+func (s *SMF) findPattern(match func(Pattern) bool, descr string) (p Pattern, err error) {
+	patterns, err := s.Patterns()
+
+	if err != nil {
+		return p, err
+	}
+
+	for _, p := range patterns {
+		if match(p) {
+			return p, nil
+		}
+	}
+
+	return p, fmt.Errorf("no pattern with %s", descr)
+}
+
+// Chain returns a single track (SMF0) file that plays the patterns with the given sequence numbers one after another.
+// Each pattern starts at the end of the previous one (see Track.EndTicks). Patterns may be repeated.
+//
+// The sequence numbers of the patterns are left out and their names are turned into meta.Marker messages,
+// so that the start of each pattern is visible. Use ToSMF1 on the result to get a multi track file.
+func (s *SMF) Chain(numbers ...uint16) (*SMF, error) {
+	var chained Track
+	var offset uint64
+
+	for _, n := range numbers {
+		p, err := s.Pattern(n)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ev := range p.Track.Events {
+			switch v := ev.Message.(type) {
+			case meta.SequenceNo:
+				continue
+			case meta.Sequence:
+				ev.Message = meta.Marker(v.Text())
+			}
+
+			ev.AbsTicks += offset
+			chained.Events = append(chained.Events, ev)
+		}
+
+		offset += p.Track.EndTicks()
+	}
+
+	chained.End = offset
+
+	return &SMF{Format: smf.SMF0, TimeFormat: s.TimeFormat, Tracks: []*Track{&chained}}, nil
+}
+
+// NewSMF2 returns a SMF2 file with a track for each of the given patterns.
+// Each track starts with the sequence number and (if not empty) the name of the pattern.
+// Existing sequence numbers and names at the start of the tracks are replaced.
+func NewSMF2(timeformat smf.TimeFormat, patterns ...Pattern) *SMF {
+	s := &SMF{Format: smf.SMF2, TimeFormat: timeformat}
+
+	for _, p := range patterns {
+		t := &Track{}
+		t.Events = append(t.Events, Event{Message: meta.SequenceNo(p.Number)})
+
+		if p.Name != "" {
+			t.Events = append(t.Events, Event{Message: meta.Sequence(p.Name)})
+		}
+
+		if p.Track != nil {
+			t.End = p.Track.End
+
+			for _, ev := range p.Track.Events {
+				if ev.AbsTicks == 0 {
+					switch ev.Message.(type) {
+					case meta.SequenceNo, meta.Sequence:
+						continue
+					}
+				}
+				t.Events = append(t.Events, ev)
+			}
+		}
+
+		s.Tracks = append(s.Tracks, t)
+	}
+
+	return s
+}
@@ -0,0 +1,97 @@
+package smftrack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func mkSMF2(t *testing.T) *SMF {
+	var verse, chorus Track
+
+	verse.Add(0, channel.Channel0.NoteOn(60, 100))
+	verse.Add(48, channel.Channel0.NoteOff(60))
+	verse.Add(96, meta.EndOfTrack)
+
+	chorus.Add(0, meta.SequenceNo(7), channel.Channel0.NoteOn(64, 100))
+	chorus.Add(24, channel.Channel0.NoteOff(64))
+
+	s := NewSMF2(smf.MetricTicks(96),
+		Pattern{Number: 1, Name: "verse", Track: &verse},
+		Pattern{Number: 2, Name: "chorus", Track: &chorus},
+	)
+
+	// write and read again
+	var bf bytes.Buffer
+
+	err := s.Write(&bf)
+	if err != nil {
+		t.Fatalf("can't write SMF2: %v", err)
+	}
+
+	return mustRead(t, bf.Bytes())
+}
+
+func TestPatterns(t *testing.T) {
+	s := mkSMF2(t)
+
+	patterns, err := s.Patterns()
+
+	if err != nil {
+		t.Fatalf("can't get patterns: %v", err)
+	}
+
+	if len(patterns) != 2 {
+		t.Fatalf("got %v patterns, wanted 2", len(patterns))
+	}
+
+	for i, expected := range []Pattern{{Number: 1, Name: "verse"}, {Number: 2, Name: "chorus"}} {
+		if patterns[i].Number != expected.Number || patterns[i].Name != expected.Name {
+			t.Errorf("pattern %v: got %v %#v, wanted %v %#v", i, patterns[i].Number, patterns[i].Name, expected.Number, expected.Name)
+		}
+	}
+
+	p, err := s.PatternByName("chorus")
+
+	if err != nil || p.Number != 2 {
+		t.Errorf("PatternByName returned %v, %v", p.Number, err)
+	}
+
+	if _, err := s.Pattern(7); err == nil {
+		t.Errorf("expected error for unknown pattern")
+	}
+
+	if _, err := mustRead(t, examples.SpecSMF1).Patterns(); err != ErrNotSMF2 {
+		t.Errorf("Patterns of SMF1 returned error %v, wanted ErrNotSMF2", err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	s, err := mkSMF2(t).Chain(2, 1, 2)
+
+	if err != nil {
+		t.Fatalf("can't chain patterns: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Marker: "chorus"
+Track 0@0 channel.NoteOn channel 0 key 64 velocity 100
+Track 0@24 channel.NoteOff channel 0 key 64
+Track 0@24 meta.Marker: "verse"
+Track 0@24 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@72 channel.NoteOff channel 0 key 60
+Track 0@120 meta.Marker: "chorus"
+Track 0@120 channel.NoteOn channel 0 key 64 velocity 100
+Track 0@144 channel.NoteOff channel 0 key 64
+Track 0@144 end
+`
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
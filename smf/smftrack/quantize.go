@@ -0,0 +1,285 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+// QuantizeOption is an option for Quantize
+type QuantizeOption func(*quantizer)
+
+// Triplet lets Quantize use a triplet grid (2/3 of the note value)
+func Triplet() QuantizeOption {
+	return func(q *quantizer) {
+		q.factor = 2.0 / 3.0
+	}
+}
+
+// Dotted lets Quantize use a dotted grid (3/2 of the note value)
+func Dotted() QuantizeOption {
+	return func(q *quantizer) {
+		q.factor = 3.0 / 2.0
+	}
+}
+
+// Strength sets how far the notes are moved towards the grid in percent (0-100).
+// Without passing this option, the notes are moved onto the grid (100).
+func Strength(percent uint8) QuantizeOption {
+	return func(q *quantizer) {
+		q.strength = clampPercent(percent)
+	}
+}
+
+// Swing delays every second grid position by the given percentage of the grid (0-99).
+// 33 gives a triplet feel. Without passing this option, there is no swing (0).
+func Swing(percent uint8) QuantizeOption {
+	return func(q *quantizer) {
+		q.swing = clampPercent(percent)
+
+		if q.swing > 99 {
+			q.swing = 99
+		}
+	}
+}
+
+// Window sets the range around each grid position in which notes are quantized, in percent
+// of half the grid (0-100). Notes outside the window are not moved.
+// Without passing this option, all notes are quantized (100).
+func Window(percent uint8) QuantizeOption {
+	return func(q *quantizer) {
+		q.window = clampPercent(percent)
+	}
+}
+
+// QuantizeEnds lets Quantize also snap the ends of the notes to the grid. A note never gets shorter than one grid step.
+// Without passing this option, the notes keep their durations.
+func QuantizeEnds() QuantizeOption {
+	return func(q *quantizer) {
+		q.ends = true
+	}
+}
+
+func clampPercent(percent uint8) float64 {
+	if percent > 100 {
+		return 100
+	}
+	return float64(percent)
+}
+
+type quantizer struct {
+	factor   float64
+	strength float64
+	swing    float64
+	window   float64
+	ends     bool
+
+	// grid is the distance between the (unswung) grid positions in ticks
+	grid float64
+}
+
+// Quantize returns a copy of the SMF with the starts of the notes of all tracks moved towards a grid of the given
+// note value (e.g. 16 for sixteenth notes), see the QuantizeOptions.
+//
+// Non note messages stay at their position, except for channel messages that directly precede a note on message
+// of the same channel at the same position (e.g. program changes): they move with the note.
+// At the same position, note off messages come before the other messages.
+// Notes don't get shorter than one tick.
+// The time format of the SMF must be smf.MetricTicks.
+func (s *SMF) Quantize(noteValue uint8, options ...QuantizeOption) (*SMF, error) {
+	resolution, isMetric := s.TimeFormat.(smf.MetricTicks)
+
+	if !isMetric {
+		return nil, ErrTimeCode
+	}
+
+	q := &quantizer{factor: 1, strength: 100, window: 100}
+
+	for _, opt := range options {
+		opt(q)
+	}
+
+	if noteValue == 0 {
+		return nil, fmt.Errorf("invalid note value 0")
+	}
+
+	q.grid = float64(resolution.Ticks4th()) * 4 / float64(noteValue) * q.factor
+
+	if q.grid < 1 {
+		return nil, fmt.Errorf("grid of 1/%v notes is too fine for resolution %v", noteValue, resolution)
+	}
+
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	for _, t := range s.Tracks {
+		res.Tracks = append(res.Tracks, t.moveNotes(q.move))
+	}
+
+	return res, nil
+}
+
+// position returns the position of the k-th grid position
+func (q *quantizer) position(k float64) float64 {
+	pos := k * q.grid
+
+	if math.Mod(k, 2) == 1 {
+		pos += q.grid * q.swing / 100
+	}
+
+	return pos
+}
+
+// target returns the nearest grid position
+func (q *quantizer) target(pos uint64) float64 {
+	k := math.Floor(float64(pos) / q.grid)
+	target := q.position(k)
+
+	for _, c := range []float64{k - 1, k + 1} {
+		if c < 0 {
+			continue
+		}
+
+		if p := q.position(c); math.Abs(p-float64(pos)) < math.Abs(target-float64(pos)) {
+			target = p
+		}
+	}
+
+	return target
+}
+
+func (q *quantizer) quantize(pos uint64) uint64 {
+	target := q.target(pos)
+	dist := target - float64(pos)
+
+	if math.Abs(dist) > q.grid/2*q.window/100 {
+		return pos
+	}
+
+	return uint64(math.Max(0, math.Round(float64(pos)+dist*q.strength/100)))
+}
+
+// move returns the new start and end of the note
+func (q *quantizer) move(n note) (start, end uint64) {
+	start = q.quantize(n.start)
+
+	if !q.ends {
+		return start, start + n.end - n.start
+	}
+
+	end = q.quantize(n.end)
+
+	if end <= start {
+		end = start + uint64(math.Round(q.grid))
+	}
+
+	return start, end
+}
+
+// note is a note of a track
+type note struct {
+	// on and off are the indices of the note on and note off event (-1 if the note is not ended)
+	on, off int
+
+	start, end uint64
+}
+
+// notes returns the notes of the track. Overlapping notes of the same key are paired first in first out.
+func (t *Track) notes() (notes []note) {
+	open := map[[2]uint8][]int{}
+
+	for i, ev := range t.Events {
+		ch, key, isOn, isOff := noteMessage(ev.Message)
+
+		switch {
+		case isOn:
+			open[[2]uint8{ch, key}] = append(open[[2]uint8{ch, key}], len(notes))
+			notes = append(notes, note{on: i, off: -1, start: ev.AbsTicks, end: ev.AbsTicks})
+		case isOff:
+			k := [2]uint8{ch, key}
+
+			if len(open[k]) > 0 {
+				n := &notes[open[k][0]]
+				n.off, n.end = i, ev.AbsTicks
+				open[k] = open[k][1:]
+			}
+		}
+	}
+
+	return
+}
+
+// noteMessage returns the channel and key of note messages and whether the message is a note on or note off
+func noteMessage(m midi.Message) (ch, key uint8, isOn, isOff bool) {
+	switch v := m.(type) {
+	case channel.NoteOn:
+		return v.Channel(), v.Key(), v.Velocity() > 0, v.Velocity() == 0
+	case channel.NoteOff:
+		return v.Channel(), v.Key(), false, true
+	case channel.NoteOffVelocity:
+		return v.Channel(), v.Key(), false, true
+	}
+	return
+}
+
+// moveNotes returns a copy of the track with the notes moved to the positions returned by move.
+// The channel messages that directly precede a note on of the same channel at the same position move with it.
+func (t *Track) moveNotes(move func(note) (start, end uint64)) *Track {
+	nt := &Track{End: t.End, Events: make([]Event, len(t.Events))}
+	copy(nt.Events, t.Events)
+
+	for _, n := range t.notes() {
+		start, end := move(n)
+		nt.Events[n.on].AbsTicks = start
+		ch, _, _, _ := noteMessage(t.Events[n.on].Message)
+
+		for i := n.on - 1; i >= 0 && t.Events[i].AbsTicks == n.start && isAnchored(t.Events[i].Message, ch); i-- {
+			nt.Events[i].AbsTicks = start
+		}
+
+		if n.off < 0 {
+			continue
+		}
+
+		// don't let the note off come before the note on
+		if end <= start {
+			end = start + 1
+		}
+
+		nt.Events[n.off].AbsTicks = end
+	}
+
+	sort.SliceStable(nt.Events, func(a, b int) bool {
+		ea, eb := nt.Events[a], nt.Events[b]
+
+		if ea.AbsTicks != eb.AbsTicks {
+			return ea.AbsTicks < eb.AbsTicks
+		}
+
+		_, _, _, aIsOff := noteMessage(ea.Message)
+		_, _, _, bIsOff := noteMessage(eb.Message)
+		return aIsOff && !bIsOff
+	})
+
+	return nt
+}
+
+// isAnchored returns true for channel messages of the given channel that are not note messages
+func isAnchored(m midi.Message, ch uint8) bool {
+	switch v := m.(type) {
+	case channel.ProgramChange:
+		return v.Channel() == ch
+	case channel.ControlChange:
+		return v.Channel() == ch
+	case channel.Pitchbend:
+		return v.Channel() == ch
+	case channel.Aftertouch:
+		return v.Channel() == ch
+	case channel.PolyAftertouch:
+		return v.Channel() == ch
+	}
+	return false
+}
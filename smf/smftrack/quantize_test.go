@@ -0,0 +1,110 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+// noteStarts returns the start and end of each note of the first track
+func noteStarts(s *SMF) string {
+	var bf bytes.Buffer
+	for _, n := range s.Tracks[0].notes() {
+		fmt.Fprintf(&bf, "%v-%v ", n.start, n.end)
+	}
+	return bf.String()
+}
+
+func mkNotes(positions ...uint64) *SMF {
+	var tr Track
+	ch := channel.Channel0
+
+	for i := 0; i < len(positions); i += 2 {
+		tr.Add(positions[i], ch.NoteOn(uint8(60+i), 100))
+		tr.Add(positions[i+1], ch.NoteOff(uint8(60+i)))
+	}
+
+	return &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+}
+
+func TestQuantize(t *testing.T) {
+	tests := []struct {
+		descr     string
+		notes     []uint64
+		noteValue uint8
+		options   []QuantizeOption
+		expected  string
+	}{
+		{"sixteenth", []uint64{5, 15, 20, 30, 50, 100}, 16, nil, "0-10 24-34 48-98 "},
+		{"eighth", []uint64{5, 15, 20, 30, 50, 100}, 8, nil, "0-10 0-10 48-98 "},
+		{"strength", []uint64{5, 15, 20, 30}, 16, []QuantizeOption{Strength(50)}, "3-13 22-32 "},
+		{"window", []uint64{5, 15, 20, 30, 35, 40}, 16, []QuantizeOption{Window(50)}, "0-10 24-34 35-40 "},
+		{"swing", []uint64{30, 40, 50, 60}, 16, []QuantizeOption{Swing(33)}, "32-42 48-58 "},
+		{"triplet", []uint64{30, 40, 42, 50}, 16, []QuantizeOption{Triplet()}, "32-42 48-56 "},
+		{"dotted", []uint64{30, 40, 50, 60}, 16, []QuantizeOption{Dotted()}, "36-46 36-46 "},
+		{"ends", []uint64{5, 30, 50, 53}, 16, []QuantizeOption{QuantizeEnds()}, "0-24 48-72 "},
+	}
+
+	for _, test := range tests {
+		q, err := mkNotes(test.notes...).Quantize(test.noteValue, test.options...)
+
+		if err != nil {
+			t.Errorf("[%s] can't quantize: %v", test.descr, err)
+			continue
+		}
+
+		if got := noteStarts(q); got != test.expected {
+			t.Errorf("[%s] got %q; want %q", test.descr, got, test.expected)
+		}
+	}
+}
+
+func TestQuantizeOrder(t *testing.T) {
+	var tr Track
+	ch := channel.Channel1
+
+	tr.Add(0, ch.NoteOn(60, 100))
+	tr.Add(5, channel.Channel2.ControlChange(7, 100), ch.ProgramChange(3), ch.NoteOn(62, 100))
+	tr.Add(23, ch.NoteOn(64, 100))
+	tr.Add(25, ch.NoteOff(60))
+	tr.Add(40, ch.NoteOff(62), ch.NoteOff(64))
+	tr.Add(96, channel.Channel1.Pitchbend(100))
+
+	s := &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+	q, err := s.Quantize(16, QuantizeEnds())
+
+	if err != nil {
+		t.Fatalf("can't quantize: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 channel.NoteOn channel 1 key 60 velocity 100
+Track 0@0 channel.ProgramChange channel 1 program 3
+Track 0@0 channel.NoteOn channel 1 key 62 velocity 100
+Track 0@5 channel.ControlChange channel 2 controller 7 ("Volume (MSB)") value 100
+Track 0@24 channel.NoteOff channel 1 key 60
+Track 0@24 channel.NoteOn channel 1 key 64 velocity 100
+Track 0@48 channel.NoteOff channel 1 key 62
+Track 0@48 channel.NoteOff channel 1 key 64
+Track 0@96 channel.Pitchbend channel 1 value 100 absValue 0
+Track 0@0 end
+`
+
+	if got := dump(q); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	if _, err := s.Quantize(0); err == nil {
+		t.Errorf("expected error for note value 0")
+	}
+
+	s.TimeFormat = smf.SMPTE30(4)
+
+	if _, err := s.Quantize(16); err != ErrTimeCode {
+		t.Errorf("expected ErrTimeCode, got %v", err)
+	}
+}
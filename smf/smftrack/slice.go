@@ -0,0 +1,146 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midistate"
+	"github.com/gomidi/midi/smf"
+)
+
+// Slice returns a copy of the SMF with the events of each track from the position from (inclusive)
+// to the position to (exclusive). The positions are moved, so that from becomes 0 and the tracks end at to-from.
+//
+// The state of each track at from is reproduced by chase events at position 0 (in this order):
+// the last sequence/track name (meta.Sequence), instrument name (meta.Track), program name (meta.Program),
+// device name (meta.Device) and MIDI port (meta.Port) messages, the last tempo, time signature and key signature messages and for each channel the last bank select,
+// program change, controller values, parameters, pitch bend and aftertouch messages and the notes that are held at from
+// (see midistate.Tracker.ChannelMessages). Notes that are still held at to are closed by note off messages
+// at the end of the slice and a sustain pedal that is down is released.
+func (s *SMF) Slice(from, to uint64) (*SMF, error) {
+	if s.Format == smf.SMF2 {
+		return nil, ErrSMF2
+	}
+
+	if to < from {
+		return nil, fmt.Errorf("invalid range: %v - %v", from, to)
+	}
+
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	for _, t := range s.Tracks {
+		res.Tracks = append(res.Tracks, t.slice(from, to))
+	}
+
+	return res, nil
+}
+
+// SliceTime is like Slice, but the range is given as time from the start of the file.
+// The time is converted to ticks by the tempo messages of all tracks (120 BPM before the first one).
+func (s *SMF) SliceTime(from, to time.Duration) (*SMF, error) {
+	tm, err := newTempoMap(s, s.TimeFormat)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Slice(tm.srcTicksAt(from), tm.srcTicksAt(to))
+}
+
+// srcTicksAt returns the source position of the given time
+func (tm *tempoMap) srcTicksAt(d time.Duration) uint64 {
+	us := float64(d.Nanoseconds()) / 1000
+
+	if tc, is := tm.src.(smf.TimeCode); is {
+		return uint64(math.Round(us * ticksPerMicrosecond(tc)))
+	}
+
+	i := sort.Search(len(tm.changes), func(i int) bool {
+		return tm.changes[i].time > us
+	}) - 1
+
+	c := tm.changes[i]
+	return c.srcTicks + uint64(math.Round((us-c.time)*float64(tm.src.(smf.MetricTicks).Number())/c.tempo))
+}
+
+func (t *Track) slice(from, to uint64) *Track {
+	ch := newChaser()
+	nt := &Track{End: to - from}
+	i := 0
+
+	for ; i < len(t.Events) && t.Events[i].AbsTicks < from; i++ {
+		ch.track(t.Events[i].Message)
+	}
+
+	for _, m := range ch.messages() {
+		nt.Events = append(nt.Events, Event{Message: m})
+	}
+
+	for ; i < len(t.Events) && t.Events[i].AbsTicks < to; i++ {
+		ev := t.Events[i]
+		ch.track(ev.Message)
+		ev.AbsTicks -= from
+		nt.Events = append(nt.Events, ev)
+	}
+
+	for _, m := range ch.channels.NoteOffs() {
+		nt.Events = append(nt.Events, Event{AbsTicks: to - from, Message: m})
+	}
+
+	return nt
+}
+
+// chaser tracks the state of a track
+type chaser struct {
+	sequence   midi.Message
+	instrument midi.Message
+	program    midi.Message
+	device     midi.Message
+	port       midi.Message
+	tempo      midi.Message
+	timesig    midi.Message
+	key        midi.Message
+	channels   *midistate.Tracker
+}
+
+func newChaser() *chaser {
+	return &chaser{channels: midistate.New()}
+}
+
+func (c *chaser) track(m midi.Message) {
+	switch v := m.(type) {
+	case meta.Tempo:
+		c.tempo = v
+	case meta.TimeSig:
+		c.timesig = v
+	case meta.Key:
+		c.key = v
+	case meta.Sequence:
+		c.sequence = v
+	case meta.Track:
+		c.instrument = v
+	case meta.Program:
+		c.program = v
+	case meta.Device:
+		c.device = v
+	case meta.Port:
+		c.port = v
+	default:
+		c.channels.Track(m)
+	}
+}
+
+// messages returns the messages that reproduce the state
+func (c *chaser) messages() (msgs []midi.Message) {
+	for _, m := range []midi.Message{c.sequence, c.instrument, c.program, c.device, c.port, c.tempo, c.timesig, c.key} {
+		if m != nil {
+			msgs = append(msgs, m)
+		}
+	}
+
+	return append(msgs, c.channels.Messages()...)
+}
@@ -0,0 +1,122 @@
+package smftrack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func mkSliceSMF() *SMF {
+	var tr Track
+
+	tr.Add(0,
+		meta.BPM(120),
+		meta.TimeSig{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+		channel.Channel1.ProgramChange(5),
+		channel.Channel1.ControlChange(0, 1),
+		channel.Channel1.ControlChange(7, 100),
+		channel.Channel1.NoteOn(60, 90),
+	)
+	tr.Add(48, channel.Channel1.ControlChange(7, 80), channel.Channel1.Pitchbend(200))
+	tr.Add(96, meta.BPM(60), channel.Channel1.NoteOn(64, 70))
+	tr.Add(144, channel.Channel1.NoteOff(60))
+	tr.Add(192, channel.Channel1.NoteOff(64))
+	tr.Add(288, meta.EndOfTrack)
+
+	return &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+}
+
+func TestSlice(t *testing.T) {
+	s, err := mkSliceSMF().Slice(72, 168)
+
+	if err != nil {
+		t.Fatalf("can't slice: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Tempo BPM: 120.00
+Track 0@0 meta.TimeSig 3/4 clocksperclick 24 dsqpq 8
+Track 0@0 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+Track 0@0 channel.ProgramChange channel 1 program 5
+Track 0@0 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+Track 0@0 channel.Pitchbend channel 1 value 200 absValue 0
+Track 0@0 channel.NoteOn channel 1 key 60 velocity 90
+Track 0@24 meta.Tempo BPM: 60.00
+Track 0@24 channel.NoteOn channel 1 key 64 velocity 70
+Track 0@72 channel.NoteOff channel 1 key 60
+Track 0@96 channel.NoteOff channel 1 key 64
+Track 0@96 end
+`
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	if _, err := mkSliceSMF().Slice(10, 5); err == nil {
+		t.Errorf("expected error for invalid range")
+	}
+}
+
+func TestSliceNames(t *testing.T) {
+	var tr Track
+
+	tr.Add(0, meta.Sequence("Piano"), meta.Track("Grand Piano"), meta.Program("Concert"), meta.Device("Out 1"), meta.Port(2))
+	tr.Add(10, meta.Track("Upright Piano"), meta.Text("verse"), channel.Channel0.NoteOn(60, 100))
+	tr.Add(20, channel.Channel0.NoteOff(60))
+
+	s := &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+	sliced, err := s.Slice(15, 30)
+
+	if err != nil {
+		t.Fatalf("can't slice: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Sequence: "Piano"
+Track 0@0 meta.Track: "Upright Piano"
+Track 0@0 meta.Program: "Concert"
+Track 0@0 meta.Device: "Out 1"
+Track 0@0 meta.Port: 2
+Track 0@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@5 channel.NoteOff channel 0 key 60
+Track 0@15 end
+`
+
+	if got := dump(sliced); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestSliceTime(t *testing.T) {
+	// 96 ticks at 120 BPM = 0.5s, then 60 BPM: 1s = 96 ticks
+	s, err := mkSliceSMF().SliceTime(500*time.Millisecond, 1500*time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("can't slice: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Tempo BPM: 120.00
+Track 0@0 meta.TimeSig 3/4 clocksperclick 24 dsqpq 8
+Track 0@0 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+Track 0@0 channel.ProgramChange channel 1 program 5
+Track 0@0 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+Track 0@0 channel.Pitchbend channel 1 value 200 absValue 0
+Track 0@0 channel.NoteOn channel 1 key 60 velocity 90
+Track 0@0 meta.Tempo BPM: 60.00
+Track 0@0 channel.NoteOn channel 1 key 64 velocity 70
+Track 0@48 channel.NoteOff channel 1 key 60
+Track 0@96 channel.NoteOff channel 1 key 64
+Track 0@96 end
+`
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
@@ -0,0 +1,212 @@
+package smftrack
+
+import (
+	"io"
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// Event is a MIDI message at an absolute position within a track
+type Event struct {
+	// AbsTicks is the position in ticks from the start of the track
+	AbsTicks uint64
+
+	// Message is the MIDI message (never meta.EndOfTrack)
+	Message midi.Message
+}
+
+// Track is a track of a SMF file
+type Track struct {
+	// Events are the events of the track ordered by their position.
+	// Events at the same position are kept in the order in which they have been added.
+	Events []Event
+
+	// End is the position of the meta.EndOfTrack message. If it is before the last event,
+	// the track ends with the last event.
+	End uint64
+}
+
+// Add adds the messages at the given position behind the existing events at this position.
+// meta.EndOfTrack messages move the end of the track instead.
+func (t *Track) Add(absTicks uint64, msgs ...midi.Message) {
+	i := sort.Search(len(t.Events), func(i int) bool {
+		return t.Events[i].AbsTicks > absTicks
+	})
+
+	var evts []Event
+
+	for _, m := range msgs {
+		if m == meta.EndOfTrack {
+			t.End = absTicks
+			continue
+		}
+		evts = append(evts, Event{AbsTicks: absTicks, Message: m})
+	}
+
+	t.Events = append(t.Events[:i], append(evts, t.Events[i:]...)...)
+}
+
+// EndTicks returns the position of the end of the track, which is End or the position of the last event,
+// whichever comes later
+func (t *Track) EndTicks() uint64 {
+	if n := len(t.Events); n > 0 && t.Events[n-1].AbsTicks > t.End {
+		return t.Events[n-1].AbsTicks
+	}
+	return t.End
+}
+
+// SMF is a Standard MIDI File in memory
+type SMF struct {
+	// Format is the SMF format
+	Format smf.Format
+
+	// TimeFormat is the time format (smf.MetricTicks or smf.TimeCode)
+	TimeFormat smf.TimeFormat
+
+	// Tracks are the tracks of the file
+	Tracks []*Track
+}
+
+// Read reads a SMF from src.
+// The reader is created with the smfreader.NoteOffVelocity option (so that no velocity gets lost)
+// followed by the given options.
+func Read(src io.Reader, options ...smfreader.Option) (*SMF, error) {
+	opts := append([]smfreader.Option{smfreader.NoteOffVelocity()}, options...)
+	return read(smfreader.New(src, opts...))
+}
+
+// ReadFile reads the SMF file. See Read for the options.
+func ReadFile(file string, options ...smfreader.Option) (s *SMF, err error) {
+	opts := append([]smfreader.Option{smfreader.NoteOffVelocity()}, options...)
+
+	err2 := smfreader.ReadFile(file, func(rd smf.Reader) {
+		s, err = read(rd)
+	}, opts...)
+
+	if err == nil {
+		err = err2
+	}
+
+	return
+}
+
+func read(rd smf.Reader) (*SMF, error) {
+	err := rd.ReadHeader()
+
+	if err != nil {
+		return nil, err
+	}
+
+	hd := rd.Header()
+	s := &SMF{Format: hd.Format, TimeFormat: hd.TimeFormat}
+
+	var (
+		track    *Track
+		trackNo  = int16(-1)
+		absTicks uint64
+	)
+
+	for {
+		m, err := rd.Read()
+
+		if err == smf.ErrFinished {
+			return s, nil
+		}
+
+		if err != nil {
+			return s, err
+		}
+
+		if rd.Track() != trackNo {
+			trackNo = rd.Track()
+			track = &Track{}
+			s.Tracks = append(s.Tracks, track)
+			absTicks = 0
+		}
+
+		absTicks += uint64(rd.Delta())
+
+		if m == meta.EndOfTrack {
+			track.End = absTicks
+			continue
+		}
+
+		track.Events = append(track.Events, Event{AbsTicks: absTicks, Message: m})
+	}
+}
+
+// Write writes the SMF to dest. The number of tracks, the format and the time format are passed
+// to the writer, followed by the given options.
+func (s *SMF) Write(dest io.Writer, options ...smfwriter.Option) error {
+	wr := smfwriter.New(dest, s.writerOptions(options)...)
+	return s.write(wr)
+}
+
+// WriteFile writes the SMF to the file. See Write for the options.
+func (s *SMF) WriteFile(file string, options ...smfwriter.Option) (err error) {
+	err2 := smfwriter.WriteFile(file, func(wr smf.Writer) {
+		err = s.write(wr)
+	}, s.writerOptions(options)...)
+
+	if err == nil {
+		err = err2
+	}
+
+	return
+}
+
+func (s *SMF) writerOptions(options []smfwriter.Option) []smfwriter.Option {
+	format := s.Format
+	if format == nil {
+		format = smf.SMF1
+		if len(s.Tracks) == 1 {
+			format = smf.SMF0
+		}
+	}
+
+	return append([]smfwriter.Option{
+		smfwriter.NumTracks(uint16(len(s.Tracks))),
+		smfwriter.Format(format),
+		smfwriter.TimeFormat(s.TimeFormat),
+	}, options...)
+}
+
+func (s *SMF) write(wr smf.Writer) error {
+	err := wr.WriteHeader()
+
+	if err != nil {
+		return err
+	}
+
+	for _, t := range s.Tracks {
+		var last uint64
+
+		for _, ev := range t.Events {
+			if ev.Message == meta.EndOfTrack {
+				continue
+			}
+
+			wr.SetDelta(uint32(ev.AbsTicks - last))
+			last = ev.AbsTicks
+
+			err = wr.Write(ev.Message)
+			if err != nil {
+				return err
+			}
+		}
+
+		wr.SetDelta(uint32(t.EndTicks() - last))
+		err = wr.Write(meta.EndOfTrack)
+
+		if err != nil && err != smf.ErrFinished {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func dump(s *SMF) string {
+	var bf bytes.Buffer
+	bf.WriteString("\n")
+	fmt.Fprintf(&bf, "%s %v\n", s.Format, s.TimeFormat)
+
+	for i, t := range s.Tracks {
+		for _, ev := range t.Events {
+			fmt.Fprintf(&bf, "Track %v@%v %s\n", i, ev.AbsTicks, ev.Message)
+		}
+		fmt.Fprintf(&bf, "Track %v@%v end\n", i, t.End)
+	}
+
+	return bf.String()
+}
+
+func mustRead(t *testing.T, data []byte) *SMF {
+	s, err := Read(bytes.NewReader(data))
+
+	if err != nil {
+		t.Fatalf("can't read SMF: %v", err)
+	}
+
+	return s
+}
+
+func TestReadWrite(t *testing.T) {
+	tests := [][]byte{
+		examples.SpecSMF0,
+		examples.SpecSMF1,
+	}
+
+	for i, test := range tests {
+		var bf bytes.Buffer
+
+		err := mustRead(t, test).Write(&bf)
+
+		if err != nil {
+			t.Fatalf("[%v] can't write SMF: %v", i, err)
+		}
+
+		if got, want := bf.Bytes(), test; !bytes.Equal(got, want) {
+			t.Errorf("[%v] got:\n% X\n\nwanted:\n% X\n\n", i, got, want)
+		}
+	}
+}
+
+func TestTrackAdd(t *testing.T) {
+	var tr Track
+
+	tr.Add(10, channel.Channel0.NoteOn(60, 100))
+	tr.Add(0, meta.Tempo(500000))
+	tr.Add(10, channel.Channel0.NoteOff(60))
+	tr.Add(20, meta.EndOfTrack)
+	tr.Add(5, channel.Channel0.ProgramChange(3), channel.Channel0.NoteOn(62, 100))
+
+	expected := `
+SMF0 (singletrack) 960 MetricTicks
+Track 0@0 meta.Tempo BPM: 120.00
+Track 0@5 channel.ProgramChange channel 0 program 3
+Track 0@5 channel.NoteOn channel 0 key 62 velocity 100
+Track 0@10 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@10 channel.NoteOff channel 0 key 60
+Track 0@20 end
+`
+
+	s := &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(0), Tracks: []*Track{&tr}}
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestToSMF0(t *testing.T) {
+	s0, err := mustRead(t, examples.SpecSMF1).ToSMF0()
+
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+Track 0@0 meta.Tempo BPM: 120.00
+Track 0@0 channel.ProgramChange channel 0 program 5
+Track 0@0 channel.ProgramChange channel 1 program 46
+Track 0@0 channel.ProgramChange channel 2 program 70
+Track 0@0 channel.NoteOn channel 2 key 48 velocity 96
+Track 0@0 channel.NoteOn channel 2 key 60 velocity 96
+Track 0@96 channel.NoteOn channel 1 key 67 velocity 64
+Track 0@192 channel.NoteOn channel 0 key 76 velocity 32
+Track 0@384 channel.NoteOff channel 0 key 76
+Track 0@384 channel.NoteOff channel 1 key 67
+Track 0@384 channel.NoteOff channel 2 key 48
+Track 0@384 channel.NoteOff channel 2 key 60
+Track 0@384 end
+`
+
+	if got := dump(s0); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestToSMF1(t *testing.T) {
+	var tr Track
+
+	tr.Add(0,
+		meta.Copyright("song"),
+		meta.Channel(1),
+		meta.Track("bass"),
+		meta.Tempo(500000),
+		channel.Channel1.ProgramChange(33),
+		meta.Text("no prefix"),
+		meta.Sequence("piano"),
+		meta.Program("grand"),
+		channel.Channel0.ProgramChange(1),
+	)
+	tr.Add(10, channel.Channel1.NoteOn(40, 100), channel.Channel0.NoteOn(60, 100))
+	tr.Add(20, channel.Channel0.NoteOff(60), channel.Channel1.NoteOff(40), meta.Program("unused"), meta.Marker("end"))
+	tr.Add(30, meta.EndOfTrack)
+
+	s := &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+
+	s1, err := s.ToSMF1()
+
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	expected := `
+SMF1 (multitrack) 96 MetricTicks
+Track 0@0 meta.Copyright: "song"
+Track 0@0 meta.Tempo BPM: 120.00
+Track 0@0 meta.Text: "no prefix"
+Track 0@20 meta.Marker: "end"
+Track 0@20 meta.Program: "unused"
+Track 0@30 end
+Track 1@0 meta.Sequence: "piano"
+Track 1@0 meta.Program: "grand"
+Track 1@0 channel.ProgramChange channel 0 program 1
+Track 1@10 channel.NoteOn channel 0 key 60 velocity 100
+Track 1@20 channel.NoteOff channel 0 key 60
+Track 1@30 end
+Track 2@0 meta.Channel: 1
+Track 2@0 meta.Track: "bass"
+Track 2@0 channel.ProgramChange channel 1 program 33
+Track 2@10 channel.NoteOn channel 1 key 40 velocity 100
+Track 2@20 channel.NoteOff channel 1 key 40
+Track 2@30 end
+`
+
+	if got := dump(s1); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	// converting back keeps every event
+	s0, err := s1.ToSMF0()
+
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	if got, want := len(s0.Tracks[0].Events), len(tr.Events); got != want {
+		t.Errorf("got %v events, wanted %v", got, want)
+	}
+}
+
+func TestConvertSMF2(t *testing.T) {
+	s := &SMF{Format: smf.SMF2, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{{}, {}}}
+
+	if _, err := s.ToSMF0(); err != ErrSMF2 {
+		t.Errorf("ToSMF0 returned error %v, wanted ErrSMF2", err)
+	}
+
+	if _, err := s.ToSMF1(); err != ErrSMF2 {
+		t.Errorf("ToSMF1 returned error %v, wanted ErrSMF2", err)
+	}
+
+	// a single track is rejected too
+	s.Tracks = s.Tracks[:1]
+
+	if _, err := s.ToSMF1(); err != ErrSMF2 {
+		t.Errorf("ToSMF1 returned error %v for a single track, wanted ErrSMF2", err)
+	}
+}
@@ -0,0 +1,191 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// RampCurve is the curve of a tempo ramp
+type RampCurve int
+
+const (
+	// LinearRamp changes the tempo (in BPM) by the same amount at each step
+	LinearRamp RampCurve = iota
+
+	// ExponentialRamp changes the tempo (in BPM) by the same ratio at each step
+	ExponentialRamp
+)
+
+// Duration returns the duration of the SMF, up to the end of the longest track.
+// The positions are converted to time by the tempo messages of all tracks (120 BPM before the first one).
+func (s *SMF) Duration() (time.Duration, error) {
+	tm, err := newTempoMap(s, s.TimeFormat)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var end uint64
+
+	for _, t := range s.Tracks {
+		if e := t.EndTicks(); e > end {
+			end = e
+		}
+	}
+
+	return time.Duration(math.Round(tm.timeAt(end) * 1000)), nil
+}
+
+// timeAt returns the time of the source position in microseconds
+func (tm *tempoMap) timeAt(srcTicks uint64) float64 {
+	i := sort.Search(len(tm.changes), func(i int) bool {
+		return tm.changes[i].srcTicks > srcTicks
+	}) - 1
+
+	return tm.time(tm.changes[i], srcTicks)
+}
+
+// ScaleTempo returns a copy of the SMF with the tempo messages of all tracks multiplied by the given factor
+// (e.g. 2 doubles the speed). If there is no tempo message at position 0, the scaled default tempo (120 BPM)
+// is added to the first track. The time format of the SMF must be smf.MetricTicks.
+// The scaled tempos are limited to the range of tempo messages (1 to 0xFFFFFF microseconds per quarter note).
+func (s *SMF) ScaleTempo(factor float64) (*SMF, error) {
+	if _, isMetric := s.TimeFormat.(smf.MetricTicks); !isMetric {
+		return nil, ErrTimeCode
+	}
+
+	if factor <= 0 {
+		return nil, fmt.Errorf("invalid tempo factor %v", factor)
+	}
+
+	res := s.clone()
+	hasStart := false
+
+	for _, t := range res.Tracks {
+		for i, ev := range t.Events {
+			if tempo, is := ev.Message.(meta.Tempo); is {
+				t.Events[i].Message = limitTempo(float64(tempo) / factor)
+				hasStart = hasStart || ev.AbsTicks == 0
+			}
+		}
+	}
+
+	if !hasStart {
+		first := res.firstTrack()
+		first.Events = append([]Event{{Message: limitTempo(float64(meta.BPM(120)) / factor)}}, first.Events...)
+	}
+
+	return res, nil
+}
+
+// limitTempo returns the tempo message for the given microseconds per quarter note, rounded and limited
+// to the range of the 3 bytes of the message
+func limitTempo(muSecPerQN float64) meta.Tempo {
+	return meta.Tempo(math.Max(1, math.Min(0xFFFFFF, math.Round(muSecPerQN))))
+}
+
+// TempoRamp returns a copy of the SMF with a tempo ramp from the position from to the position to:
+// the tempo messages of all tracks within this range are replaced by tempo messages every step ticks,
+// starting with startBPM at from and changing towards endBPM, following the given curve.
+// Unless there is a tempo message at to, endBPM is set at to.
+// The tempo messages are added to the first track. The time format of the SMF must be smf.MetricTicks.
+func (s *SMF) TempoRamp(from, to uint64, startBPM, endBPM float64, step uint64, curve RampCurve) (*SMF, error) {
+	if _, isMetric := s.TimeFormat.(smf.MetricTicks); !isMetric {
+		return nil, ErrTimeCode
+	}
+
+	switch {
+	case to <= from:
+		return nil, fmt.Errorf("invalid range: %v - %v", from, to)
+	case step == 0:
+		return nil, fmt.Errorf("invalid step 0")
+	case startBPM <= 0 || endBPM <= 0:
+		return nil, fmt.Errorf("invalid tempo: %v - %v BPM", startBPM, endBPM)
+	}
+
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+	hasEnd := false
+
+	for _, t := range s.Tracks {
+		nt := &Track{End: t.End}
+
+		for _, ev := range t.Events {
+			if _, is := ev.Message.(meta.Tempo); is {
+				hasEnd = hasEnd || ev.AbsTicks == to
+
+				if ev.AbsTicks >= from && ev.AbsTicks < to {
+					continue
+				}
+			}
+			nt.Events = append(nt.Events, ev)
+		}
+
+		res.Tracks = append(res.Tracks, nt)
+	}
+
+	first := res.firstTrack()
+
+	for pos := from; pos < to; pos += step {
+		x := float64(pos-from) / float64(to-from)
+		bpm := startBPM + (endBPM-startBPM)*x
+
+		if curve == ExponentialRamp {
+			bpm = startBPM * math.Pow(endBPM/startBPM, x)
+		}
+
+		first.Add(pos, meta.FractionalBPM(bpm))
+	}
+
+	if !hasEnd {
+		first.Add(to, meta.FractionalBPM(endBPM))
+	}
+
+	return res, nil
+}
+
+// FitDuration returns a copy of the SMF with the tempo messages scaled (see ScaleTempo),
+// so that the SMF has the given duration (see Duration). The precision is limited by the
+// resolution of the tempo messages (microseconds per quarter note).
+func (s *SMF) FitDuration(d time.Duration) (*SMF, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("invalid duration %v", d)
+	}
+
+	current, err := s.Duration()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if current == 0 {
+		return nil, fmt.Errorf("can't fit an empty SMF to a duration")
+	}
+
+	return s.ScaleTempo(float64(current) / float64(d))
+}
+
+// clone returns a copy of the SMF with copied tracks
+func (s *SMF) clone() *SMF {
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	for _, t := range s.Tracks {
+		nt := &Track{End: t.End, Events: make([]Event, len(t.Events))}
+		copy(nt.Events, t.Events)
+		res.Tracks = append(res.Tracks, nt)
+	}
+
+	return res
+}
+
+// firstTrack returns the first track, which is created if there is none
+func (s *SMF) firstTrack() *Track {
+	if len(s.Tracks) == 0 {
+		s.Tracks = append(s.Tracks, &Track{})
+	}
+	return s.Tracks[0]
+}
@@ -0,0 +1,171 @@
+package smftrack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func mkTempoSMF(tempos ...meta.Tempo) *SMF {
+	var conductor, t1 Track
+
+	for i, tempo := range tempos {
+		conductor.Add(uint64(i)*96, tempo)
+	}
+
+	t1.Add(0, channel.Channel0.NoteOn(60, 100))
+	t1.Add(384, channel.Channel0.NoteOff(60))
+
+	return &SMF{Format: smf.SMF1, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&conductor, &t1}}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		smf      *SMF
+		expected time.Duration
+	}{
+		{mkTempoSMF(), 2 * time.Second},
+		{mkTempoSMF(meta.BPM(60)), 4 * time.Second},
+		{mkTempoSMF(meta.BPM(60), meta.BPM(120)), 2500 * time.Millisecond},
+	}
+
+	for i, test := range tests {
+		got, err := test.smf.Duration()
+
+		if err != nil || got != test.expected {
+			t.Errorf("[%v] Duration() = %v, %v; want %v", i, got, err, test.expected)
+		}
+	}
+}
+
+func TestScaleTempo(t *testing.T) {
+	s, err := mkTempoSMF(meta.BPM(60), meta.BPM(120)).ScaleTempo(2)
+
+	if err != nil {
+		t.Fatalf("can't scale tempo: %v", err)
+	}
+
+	expected := `
+SMF1 (multitrack) 96 MetricTicks
+Track 0@0 meta.Tempo BPM: 120.00
+Track 0@96 meta.Tempo BPM: 240.00
+Track 0@0 end
+Track 1@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 1@384 channel.NoteOff channel 0 key 60
+Track 1@0 end
+`
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	// the default tempo is scaled too
+	s, _ = mkTempoSMF().ScaleTempo(0.5)
+
+	if d, _ := s.Duration(); d != 4*time.Second {
+		t.Errorf("Duration() = %v; want 4s", d)
+	}
+
+	if _, err := mkTempoSMF().ScaleTempo(0); err == nil {
+		t.Errorf("expected error for factor 0")
+	}
+
+	// the tempos are limited to the range of tempo messages
+	limits := []struct {
+		smf      *SMF
+		factor   float64
+		expected meta.Tempo
+	}{
+		{mkTempoSMF(meta.BPM(120)), 1e9, 1},
+		{mkTempoSMF(meta.BPM(120)), 1e-9, 0xFFFFFF},
+		{mkTempoSMF(), 1e9, 1},
+		{mkTempoSMF(), 1e-9, 0xFFFFFF},
+	}
+
+	for i, test := range limits {
+		s, err := test.smf.ScaleTempo(test.factor)
+
+		if err != nil {
+			t.Fatalf("[%v] can't scale tempo: %v", i, err)
+		}
+
+		if got := s.Tracks[0].Events[0].Message; got != test.expected {
+			t.Errorf("[%v] ScaleTempo(%v) = %v; want %v", i, test.factor, uint32(got.(meta.Tempo)), uint32(test.expected))
+		}
+	}
+}
+
+func TestTempoRamp(t *testing.T) {
+	s, err := mkTempoSMF(meta.BPM(100), meta.BPM(50), meta.BPM(90)).TempoRamp(0, 192, 100, 200, 48, LinearRamp)
+
+	if err != nil {
+		t.Fatalf("can't add ramp: %v", err)
+	}
+
+	expected := `
+SMF1 (multitrack) 96 MetricTicks
+Track 0@0 meta.Tempo BPM: 100.00
+Track 0@48 meta.Tempo BPM: 125.00
+Track 0@96 meta.Tempo BPM: 150.00
+Track 0@144 meta.Tempo BPM: 175.00
+Track 0@192 meta.Tempo BPM: 90.00
+Track 0@0 end
+Track 1@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 1@384 channel.NoteOff channel 0 key 60
+Track 1@0 end
+`
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	s, err = mkTempoSMF().TempoRamp(96, 288, 100, 25, 96, ExponentialRamp)
+
+	if err != nil {
+		t.Fatalf("can't add ramp: %v", err)
+	}
+
+	expected = `
+SMF1 (multitrack) 96 MetricTicks
+Track 0@96 meta.Tempo BPM: 100.00
+Track 0@192 meta.Tempo BPM: 50.00
+Track 0@288 meta.Tempo BPM: 25.00
+Track 0@0 end
+Track 1@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 1@384 channel.NoteOff channel 0 key 60
+Track 1@0 end
+`
+
+	if got := dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	if _, err := mkTempoSMF().TempoRamp(10, 10, 100, 120, 1, LinearRamp); err == nil {
+		t.Errorf("expected error for empty range")
+	}
+}
+
+func TestFitDuration(t *testing.T) {
+	s, err := mkTempoSMF(meta.BPM(60), meta.BPM(120)).FitDuration(5 * time.Second)
+
+	if err != nil {
+		t.Fatalf("can't fit duration: %v", err)
+	}
+
+	if d, _ := s.Duration(); d != 5*time.Second {
+		t.Errorf("Duration() = %v; want 5s", d)
+	}
+
+	s, err = mkTempoSMF(meta.BPM(97)).FitDuration(3333 * time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("can't fit duration: %v", err)
+	}
+
+	if d, _ := s.Duration(); d < 3333*time.Millisecond-10*time.Microsecond || d > 3333*time.Millisecond+10*time.Microsecond {
+		t.Errorf("Duration() = %v; want 3.333s", d)
+	}
+}
@@ -0,0 +1,175 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// ConvertResolution returns a copy of the SMF with the positions converted to the given resolution.
+// Since the absolute positions are rounded (and not the deltas between them), the rounding errors
+// don't accumulate: each event is at most half a tick away from its exact position.
+// The time format of the SMF must be smf.MetricTicks.
+func (s *SMF) ConvertResolution(resolution smf.MetricTicks) (*SMF, error) {
+	src, isMetric := s.TimeFormat.(smf.MetricTicks)
+
+	if !isMetric {
+		return nil, fmt.Errorf("can't convert resolution of time format %v", s.TimeFormat)
+	}
+
+	res := s.scale(uint64(resolution.Number()), uint64(src.Number()))
+	res.TimeFormat = resolution
+	return res, nil
+}
+
+// ConvertTimeFormat returns a copy of the SMF with the positions converted to the given time format.
+// Between smf.MetricTicks and smf.TimeCode the positions are converted by the tempo messages of all tracks
+// (120 BPM before the first one). The tempo messages are kept, so that the conversion can be reverted.
+// Between different smf.MetricTicks, the conversion is the same as with ConvertResolution.
+// As with ConvertResolution, the absolute positions are rounded, so that the rounding errors don't accumulate.
+func (s *SMF) ConvertTimeFormat(timeformat smf.TimeFormat) (*SMF, error) {
+	if mt, is := timeformat.(smf.MetricTicks); is {
+		if _, srcIsMetric := s.TimeFormat.(smf.MetricTicks); srcIsMetric {
+			return s.ConvertResolution(mt)
+		}
+	}
+
+	tm, err := newTempoMap(s, timeformat)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SMF{Format: s.Format, TimeFormat: timeformat}
+
+	for _, t := range s.Tracks {
+		nt := &Track{End: tm.convert(t.End), Events: make([]Event, len(t.Events))}
+
+		for i, ev := range t.Events {
+			ev.AbsTicks = tm.convert(ev.AbsTicks)
+			nt.Events[i] = ev
+		}
+
+		res.Tracks = append(res.Tracks, nt)
+	}
+
+	return res, nil
+}
+
+// tempoChange is a change of the tempo at a position of the source and the target time format
+type tempoChange struct {
+	srcTicks uint64
+	dstTicks float64
+
+	// time in microseconds
+	time float64
+
+	// microseconds per quarter note
+	tempo float64
+}
+
+// tempoMap converts between positions of two time formats
+type tempoMap struct {
+	src, dst smf.TimeFormat
+	changes  []tempoChange
+}
+
+// ticksPerMicrosecond returns the ticks per microsecond of a smf.TimeCode
+func ticksPerMicrosecond(tc smf.TimeCode) float64 {
+	fps := float64(tc.FramesPerSecond)
+
+	// 30 drop frame
+	if tc.FramesPerSecond == 29 {
+		fps = 29.97
+	}
+
+	return fps * float64(tc.SubFrames) / 1000000
+}
+
+func newTempoMap(s *SMF, dst smf.TimeFormat) (*tempoMap, error) {
+	tm := &tempoMap{src: s.TimeFormat, dst: dst}
+
+	for _, tf := range []smf.TimeFormat{s.TimeFormat, dst} {
+		switch v := tf.(type) {
+		case smf.MetricTicks:
+		case smf.TimeCode:
+			if ticksPerMicrosecond(v) == 0 {
+				return nil, fmt.Errorf("invalid time format %v", tf)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported time format %v", tf)
+		}
+	}
+
+	var tempos []Event
+
+	for _, t := range s.Tracks {
+		for _, ev := range t.Events {
+			if _, is := ev.Message.(meta.Tempo); is {
+				tempos = append(tempos, ev)
+			}
+		}
+	}
+
+	sort.SliceStable(tempos, func(a, b int) bool {
+		return tempos[a].AbsTicks < tempos[b].AbsTicks
+	})
+
+	tm.changes = append(tm.changes, tempoChange{tempo: float64(meta.BPM(120))})
+
+	for _, ev := range tempos {
+		tempo := ev.Message.(meta.Tempo)
+
+		if tempo == 0 {
+			continue
+		}
+
+		last := tm.changes[len(tm.changes)-1]
+		c := tempoChange{srcTicks: ev.AbsTicks, tempo: float64(tempo)}
+		c.time = tm.time(last, ev.AbsTicks)
+		c.dstTicks = tm.dstTicks(last, c.time)
+
+		// a later tempo message at the same position wins
+		if last.srcTicks == c.srcTicks {
+			tm.changes[len(tm.changes)-1] = c
+			continue
+		}
+
+		tm.changes = append(tm.changes, c)
+	}
+
+	return tm, nil
+}
+
+// time returns the time of the source position, based on the tempo change before
+func (tm *tempoMap) time(c tempoChange, srcTicks uint64) float64 {
+	switch v := tm.src.(type) {
+	case smf.MetricTicks:
+		return c.time + float64(srcTicks-c.srcTicks)*c.tempo/float64(v.Number())
+	default:
+		return float64(srcTicks) / ticksPerMicrosecond(v.(smf.TimeCode))
+	}
+}
+
+// dstTicks returns the target position of the time, based on the tempo change before
+func (tm *tempoMap) dstTicks(c tempoChange, time float64) float64 {
+	switch v := tm.dst.(type) {
+	case smf.MetricTicks:
+		return c.dstTicks + (time-c.time)*float64(v.Number())/c.tempo
+	default:
+		return time * ticksPerMicrosecond(v.(smf.TimeCode))
+	}
+}
+
+// convert converts the source position to the target position
+func (tm *tempoMap) convert(srcTicks uint64) uint64 {
+	i := sort.Search(len(tm.changes), func(i int) bool {
+		return tm.changes[i].srcTicks > srcTicks
+	}) - 1
+
+	c := tm.changes[i]
+	return uint64(math.Round(tm.dstTicks(c, tm.time(c, srcTicks))))
+}
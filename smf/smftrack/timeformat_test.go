@@ -0,0 +1,109 @@
+package smftrack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestConvertResolution(t *testing.T) {
+	var tr Track
+
+	for i := uint64(0); i <= 5; i++ {
+		tr.Add(i*3, channel.Channel0.NoteOn(60, 100))
+	}
+
+	s := &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(480), Tracks: []*Track{&tr}}
+
+	res, err := s.ConvertResolution(smf.MetricTicks(96))
+
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	expected := []uint64{0, 1, 1, 2, 2, 3}
+
+	for i, ev := range res.Tracks[0].Events {
+		if ev.AbsTicks != expected[i] {
+			t.Errorf("event %v: got position %v, wanted %v", i, ev.AbsTicks, expected[i])
+		}
+	}
+
+	if _, err := (&SMF{TimeFormat: smf.SMPTE25(40)}).ConvertResolution(smf.MetricTicks(96)); err == nil {
+		t.Errorf("expected error for time code")
+	}
+}
+
+func TestConvertResolutionRoundTrip(t *testing.T) {
+	s := mustRead(t, examples.SpecSMF1)
+
+	s960, err := s.ConvertResolution(smf.MetricTicks(960))
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	if got, want := s960.Tracks[1].Events[1].AbsTicks, uint64(1920); got != want {
+		t.Errorf("got position %v, wanted %v", got, want)
+	}
+
+	s96, err := s960.ConvertTimeFormat(smf.MetricTicks(96))
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	var bf bytes.Buffer
+
+	err = s96.Write(&bf)
+	if err != nil {
+		t.Fatalf("can't write: %v", err)
+	}
+
+	if got, want := bf.Bytes(), examples.SpecSMF1; !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, want)
+	}
+}
+
+func TestConvertTimeFormat(t *testing.T) {
+	var tr Track
+
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(96, meta.BPM(60), channel.Channel0.NoteOn(62, 100))
+	tr.Add(192, channel.Channel0.NoteOn(64, 100))
+	tr.Add(240, meta.EndOfTrack)
+
+	s := &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+
+	tc, err := s.ConvertTimeFormat(smf.SMPTE25(40))
+
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	expected := `
+SMF0 (singletrack) SMPTE25 40 subframes
+Track 0@0 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@500 meta.Tempo BPM: 60.00
+Track 0@500 channel.NoteOn channel 0 key 62 velocity 100
+Track 0@1500 channel.NoteOn channel 0 key 64 velocity 100
+Track 0@2000 end
+`
+
+	if got := dump(tc); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	// and back again
+	mt, err := tc.ConvertTimeFormat(smf.MetricTicks(96))
+
+	if err != nil {
+		t.Fatalf("can't convert: %v", err)
+	}
+
+	if got, want := dump(mt), dump(s); got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}
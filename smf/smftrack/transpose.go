@@ -0,0 +1,197 @@
+package smftrack
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/meta/key"
+)
+
+// TransposeOption is an option for Transpose and TransposeDiatonic
+type TransposeOption func(*transposer)
+
+// ExcludeDrums excludes the General MIDI drum channel (channel 9, counting from 0) from the transposition
+func ExcludeDrums() TransposeOption {
+	return func(t *transposer) {
+		t.excludeDrums = true
+	}
+}
+
+// DropOutOfRange drops the notes (and polyphonic aftertouch messages) that would be transposed out of the range 0-127.
+// Without passing this option, they are clamped to 0 or 127.
+func DropOutOfRange() TransposeOption {
+	return func(t *transposer) {
+		t.drop = true
+	}
+}
+
+type transposer struct {
+	excludeDrums bool
+	drop         bool
+}
+
+// drumChannel is the channel of the General MIDI drums
+const drumChannel = 9
+
+// Transpose returns a copy of the SMF with the keys of the note and polyphonic aftertouch messages of all tracks
+// transposed by the given semitones. The key signatures are transposed too, see key.Transpose.
+func (s *SMF) Transpose(semitones int, options ...TransposeOption) *SMF {
+	tr := newTransposer(options)
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	for _, t := range s.Tracks {
+		nt := tr.transpose(t, func(absTicks uint64, k uint8) int {
+			return int(k) + semitones
+		})
+
+		for i, ev := range nt.Events {
+			if k, is := ev.Message.(meta.Key); is {
+				nt.Events[i].Message = key.Transpose(k, semitones)
+			}
+		}
+
+		res.Tracks = append(res.Tracks, nt)
+	}
+
+	return res
+}
+
+// TransposeDiatonic returns a copy of the SMF with the keys of the note and polyphonic aftertouch messages of all tracks
+// moved by the given steps within the scale of the current key signature (of any track).
+// Before the first key signature, C major is assumed. Minor keys use the natural minor scale.
+// A key that is not part of the scale keeps its distance to the next lower key of the scale.
+// The key signatures are not changed.
+func (s *SMF) TransposeDiatonic(steps int, options ...TransposeOption) *SMF {
+	tr := newTransposer(options)
+	keys := s.keySignatures()
+	res := &SMF{Format: s.Format, TimeFormat: s.TimeFormat}
+
+	for _, t := range s.Tracks {
+		res.Tracks = append(res.Tracks, tr.transpose(t, func(absTicks uint64, k uint8) int {
+			return key.Diatonic(keys.at(absTicks), int(k), steps)
+		}))
+	}
+
+	return res
+}
+
+func newTransposer(options []TransposeOption) *transposer {
+	tr := &transposer{}
+
+	for _, opt := range options {
+		opt(tr)
+	}
+
+	return tr
+}
+
+// transpose returns a copy of the track with the keys changed by the given function.
+// A note off gets the same key as its note on.
+func (tr *transposer) transpose(t *Track, newKey func(absTicks uint64, key uint8) int) *Track {
+	nt := &Track{End: t.End}
+	keys := map[int]int{}
+
+	for _, n := range t.notes() {
+		_, k, _, _ := noteMessage(t.Events[n.on].Message)
+		keys[n.on] = newKey(t.Events[n.on].AbsTicks, k)
+
+		if n.off >= 0 {
+			keys[n.off] = keys[n.on]
+		}
+	}
+
+	for i, ev := range t.Events {
+		var ch, k uint8
+
+		switch v := ev.Message.(type) {
+		case channel.NoteOn:
+			ch, k = v.Channel(), v.Key()
+		case channel.NoteOff:
+			ch, k = v.Channel(), v.Key()
+		case channel.NoteOffVelocity:
+			ch, k = v.Channel(), v.Key()
+		case channel.PolyAftertouch:
+			ch, k = v.Channel(), v.Key()
+		default:
+			nt.Events = append(nt.Events, ev)
+			continue
+		}
+
+		if tr.excludeDrums && ch == drumChannel {
+			nt.Events = append(nt.Events, ev)
+			continue
+		}
+
+		nk, has := keys[i]
+
+		if !has {
+			nk = newKey(ev.AbsTicks, k)
+		}
+
+		if nk < 0 || nk > 127 {
+			if tr.drop {
+				continue
+			}
+
+			if nk < 0 {
+				nk = 0
+			} else {
+				nk = 127
+			}
+		}
+
+		ev.Message = withKey(ev.Message, uint8(nk))
+		nt.Events = append(nt.Events, ev)
+	}
+
+	return nt
+}
+
+// withKey returns the note or polyphonic aftertouch message with the given key
+func withKey(m midi.Message, k uint8) midi.Message {
+	switch v := m.(type) {
+	case channel.NoteOn:
+		return channel.Channel(v.Channel()).NoteOn(k, v.Velocity())
+	case channel.NoteOff:
+		return channel.Channel(v.Channel()).NoteOff(k)
+	case channel.NoteOffVelocity:
+		return channel.Channel(v.Channel()).NoteOffVelocity(k, v.Velocity())
+	case channel.PolyAftertouch:
+		return channel.Channel(v.Channel()).PolyAftertouch(k, v.Pressure())
+	}
+	return m
+}
+
+// keyMap are the key signatures of a SMF, ordered by their position
+type keyMap []Event
+
+func (s *SMF) keySignatures() (keys keyMap) {
+	for _, t := range s.Tracks {
+		for _, ev := range t.Events {
+			if _, is := ev.Message.(meta.Key); is {
+				keys = append(keys, ev)
+			}
+		}
+	}
+
+	sort.SliceStable(keys, func(a, b int) bool {
+		return keys[a].AbsTicks < keys[b].AbsTicks
+	})
+
+	return
+}
+
+// at returns the key signature at the given position
+func (keys keyMap) at(absTicks uint64) meta.Key {
+	i := sort.Search(len(keys), func(i int) bool {
+		return keys[i].AbsTicks > absTicks
+	})
+
+	if i == 0 {
+		return key.CMaj()
+	}
+
+	return keys[i-1].Message.(meta.Key)
+}
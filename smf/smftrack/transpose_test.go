@@ -0,0 +1,126 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta/key"
+	"github.com/gomidi/midi/smf"
+)
+
+func mkTransposeSMF() *SMF {
+	var tr Track
+	ch := channel.Channel0
+
+	tr.Add(0, key.FMaj(), ch.NoteOn(60, 100), channel.Channel9.NoteOn(36, 100), ch.NoteOn(125, 100))
+	tr.Add(10, ch.PolyAftertouch(60, 20))
+	tr.Add(20, ch.NoteOff(60), channel.Channel9.NoteOff(36), ch.NoteOffVelocity(125, 30))
+
+	return &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+}
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		descr     string
+		semitones int
+		options   []TransposeOption
+		expected  string
+	}{
+		{
+			"clamp",
+			5,
+			nil,
+			`
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Key: B♭ maj.
+Track 0@0 channel.NoteOn channel 0 key 65 velocity 100
+Track 0@0 channel.NoteOn channel 9 key 41 velocity 100
+Track 0@0 channel.NoteOn channel 0 key 127 velocity 100
+Track 0@10 channel.PolyAftertouch channel 0 key 65 pressure 20
+Track 0@20 channel.NoteOff channel 0 key 65
+Track 0@20 channel.NoteOff channel 9 key 41
+Track 0@20 channel.NoteOffVelocity channel 0 key 127 velocity 30
+Track 0@0 end
+`,
+		},
+		{
+			"drop and exclude drums",
+			5,
+			[]TransposeOption{DropOutOfRange(), ExcludeDrums()},
+			`
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Key: B♭ maj.
+Track 0@0 channel.NoteOn channel 0 key 65 velocity 100
+Track 0@0 channel.NoteOn channel 9 key 36 velocity 100
+Track 0@10 channel.PolyAftertouch channel 0 key 65 pressure 20
+Track 0@20 channel.NoteOff channel 0 key 65
+Track 0@20 channel.NoteOff channel 9 key 36
+Track 0@0 end
+`,
+		},
+		{
+			"down",
+			-1,
+			nil,
+			`
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 meta.Key: E maj.
+Track 0@0 channel.NoteOn channel 0 key 59 velocity 100
+Track 0@0 channel.NoteOn channel 9 key 35 velocity 100
+Track 0@0 channel.NoteOn channel 0 key 124 velocity 100
+Track 0@10 channel.PolyAftertouch channel 0 key 59 pressure 20
+Track 0@20 channel.NoteOff channel 0 key 59
+Track 0@20 channel.NoteOff channel 9 key 35
+Track 0@20 channel.NoteOffVelocity channel 0 key 124 velocity 30
+Track 0@0 end
+`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := dump(mkTransposeSMF().Transpose(test.semitones, test.options...)); got != test.expected {
+			t.Errorf("[%s] got:\n%s\nwanted:\n%s", test.descr, got, test.expected)
+		}
+	}
+}
+
+func TestTransposeDiatonic(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+
+	// C major: C, E, F#(chromatic), B
+	tr.Add(0, ch.NoteOn(60, 100), ch.NoteOn(64, 100), ch.NoteOn(66, 100), ch.NoteOn(71, 100))
+	// the key change does not affect the note off messages
+	tr.Add(10, key.AMin())
+	// A minor: A, C
+	tr.Add(20, ch.NoteOff(60), ch.NoteOff(64), ch.NoteOff(66), ch.NoteOff(71), ch.NoteOn(57, 100), ch.NoteOn(48, 100))
+
+	s := &SMF{Format: smf.SMF0, TimeFormat: smf.MetricTicks(96), Tracks: []*Track{&tr}}
+
+	expected := `
+SMF0 (singletrack) 96 MetricTicks
+Track 0@0 channel.NoteOn channel 0 key 64 velocity 100
+Track 0@0 channel.NoteOn channel 0 key 67 velocity 100
+Track 0@0 channel.NoteOn channel 0 key 70 velocity 100
+Track 0@0 channel.NoteOn channel 0 key 74 velocity 100
+Track 0@10 meta.Key: A min.
+Track 0@20 channel.NoteOff channel 0 key 64
+Track 0@20 channel.NoteOff channel 0 key 67
+Track 0@20 channel.NoteOff channel 0 key 70
+Track 0@20 channel.NoteOff channel 0 key 74
+Track 0@20 channel.NoteOn channel 0 key 60 velocity 100
+Track 0@20 channel.NoteOn channel 0 key 52 velocity 100
+Track 0@0 end
+`
+
+	if got := dump(s.TransposeDiatonic(2)); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	// and back
+	back := s.TransposeDiatonic(2).TransposeDiatonic(-2)
+
+	if got, expected := dump(back), dump(s); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
@@ -0,0 +1,102 @@
+package smfwriter
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// AbsWriter is a smf.Writer that also allows to set the position of the next messages in absolute ticks
+// from the start of the track. The writer returned by New (and passed to the callback of WriteFile) is an AbsWriter.
+type AbsWriter interface {
+	smf.Writer
+
+	// SetAbsTicks sets the position of the next message(s) in ticks from the start of the current track.
+	//
+	// With the AbsoluteTicks option the messages may be written in any order.
+	// Without it, the messages must be written in order and SetAbsTicks sets the delta to the distance
+	// to the last written message (or 0, if ticks is before that message).
+	SetAbsTicks(ticks uint32)
+}
+
+var _ AbsWriter = &writer{}
+
+// absMessage is a message that is written with the AbsoluteTicks option
+type absMessage struct {
+	ticks uint32
+	order uint8
+	msg   midi.Message
+}
+
+// order of simultaneous messages
+const (
+	orderMeta uint8 = iota
+	orderNoteOff
+	orderOther
+	orderNoteOn
+)
+
+// absOrder returns the order of a message among messages at the same position
+func absOrder(m midi.Message) uint8 {
+	switch v := m.(type) {
+	case meta.Message:
+		return orderMeta
+	case channel.NoteOff, channel.NoteOffVelocity:
+		return orderNoteOff
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return orderNoteOff
+		}
+		return orderNoteOn
+	default:
+		return orderOther
+	}
+}
+
+// SetAbsTicks sets the position of the next message(s) in ticks from the start of the current track.
+func (w *writer) SetAbsTicks(ticks uint32) {
+	if w.absolute {
+		w.absPos = ticks
+		return
+	}
+
+	w.deltatime = 0
+	if ticks > w.absPos {
+		w.deltatime = ticks - w.absPos
+	}
+}
+
+// addAbsMessage keeps the message until the end of the track
+func (w *writer) addAbsMessage(m midi.Message) {
+	w.absMessages = append(w.absMessages, absMessage{ticks: w.absPos, order: absOrder(m), msg: m})
+}
+
+// flushAbsMessages sorts the messages of the track and adds them with their deltas.
+// It returns the delta for the meta.EndOfTrack message that is placed at the current position
+// or after the last message, whichever comes later.
+func (w *writer) flushAbsMessages() (endDelta uint32) {
+	sort.SliceStable(w.absMessages, func(a, b int) bool {
+		if w.absMessages[a].ticks != w.absMessages[b].ticks {
+			return w.absMessages[a].ticks < w.absMessages[b].ticks
+		}
+		return w.absMessages[a].order < w.absMessages[b].order
+	})
+
+	var last uint32
+
+	for _, am := range w.absMessages {
+		w.addMessage(am.ticks-last, am.msg)
+		last = am.ticks
+	}
+
+	w.absMessages = w.absMessages[:0]
+
+	if w.absPos > last {
+		endDelta = w.absPos - last
+	}
+
+	return
+}
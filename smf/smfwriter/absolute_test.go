@@ -0,0 +1,99 @@
+package smfwriter
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+func TestAbsoluteTicks(t *testing.T) {
+	var bf bytes.Buffer
+
+	resolution := smf.MetricTicks(96)
+
+	wr := New(&bf, TimeFormat(resolution), Format(smf.SMF0), AbsoluteTicks()).(AbsWriter)
+
+	// the messages of examples.SpecSMF0 in a different order
+	wr.SetAbsTicks(resolution.Ticks4th() * 4)
+	wr.Write(channel.Channel2.NoteOffVelocity(48, 64))
+	wr.Write(channel.Channel2.NoteOffVelocity(60, 64))
+
+	wr.SetAbsTicks(0)
+	wr.Write(channel.Channel2.NoteOn(48, 96))
+	wr.Write(channel.Channel2.NoteOn(60, 96))
+	wr.Write(channel.Channel0.ProgramChange(5))
+	wr.Write(channel.Channel1.ProgramChange(46))
+	wr.Write(channel.Channel2.ProgramChange(70))
+
+	wr.SetDelta(resolution.Ticks4th() * 2)
+	wr.Write(channel.Channel0.NoteOn(76, 32))
+
+	wr.SetAbsTicks(0)
+	wr.Write(meta.TimeSig{
+		Numerator:                4,
+		Denominator:              4,
+		ClocksPerClick:           24,
+		DemiSemiQuaverPerQuarter: 8,
+	})
+	wr.Write(meta.BPM(120))
+
+	wr.SetAbsTicks(resolution.Ticks4th())
+	wr.Write(channel.Channel1.NoteOn(67, 64))
+
+	wr.SetAbsTicks(resolution.Ticks4th() * 4)
+	wr.Write(channel.Channel1.NoteOffVelocity(67, 64))
+	wr.Write(channel.Channel0.NoteOffVelocity(76, 64))
+
+	wr.SetAbsTicks(0)
+	wr.Write(meta.EndOfTrack)
+
+	if got, want := bf.Bytes(), examples.SpecSMF0; !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, want)
+	}
+}
+
+func TestAbsoluteTicksOrder(t *testing.T) {
+	var bf bytes.Buffer
+
+	wr := New(&bf, AbsoluteTicks()).(AbsWriter)
+
+	wr.SetAbsTicks(10)
+	wr.Write(channel.Channel0.NoteOn(60, 100))
+	wr.Write(channel.Channel0.ProgramChange(3))
+	wr.Write(channel.Channel0.NoteOff(60))
+	wr.Write(meta.Marker("A"))
+	wr.SetAbsTicks(0)
+	wr.Write(channel.Channel0.NoteOn(60, 100))
+	wr.SetAbsTicks(20)
+	wr.Write(meta.EndOfTrack)
+
+	var res bytes.Buffer
+
+	rd := smfreader.New(bytes.NewReader(bf.Bytes()))
+
+	for {
+		m, err := rd.Read()
+		if err != nil {
+			break
+		}
+		fmt.Fprintf(&res, "[%v] %s\n", rd.Delta(), m)
+	}
+
+	expected := `[0] channel.NoteOn channel 0 key 60 velocity 100
+[10] meta.Marker: "A"
+[0] channel.NoteOff channel 0 key 60
+[0] channel.ProgramChange channel 0 program 3
+[0] channel.NoteOn channel 0 key 60 velocity 100
+[10] meta.EndOfTrack
+`
+
+	if got := res.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
@@ -0,0 +1,189 @@
+package smfwriter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/gomidi/midi/internal/runningstatus"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+/*
+writing of files with the DynamicTracks option:
+
+If the output is an io.WriteSeeker, the header is written with 0 tracks and each track is written
+with a length of 0 and streamed through a buffer. When a track is finished, the buffer is flushed and
+the length is patched and when the writer is closed, the format and the number of tracks in the header are patched.
+
+Otherwise every finished track is added to the tracks buffer and the header and the tracks are
+written when the writer is closed.
+*/
+
+// startDynamic is called instead of writing the header
+func (w *writer) startDynamic() error {
+	w.header.NumTracks = 0
+
+	ws, ok := w.output.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+
+	pos, err := ws.Seek(0, io.SeekCurrent)
+
+	// not seekable (e.g. a pipe), so keep the tracks in memory
+	if err != nil {
+		return nil
+	}
+
+	w.seeker = ws
+	w.buffered = bufio.NewWriter(ws)
+	w.headerPos = pos
+	return w.writeHeader(ws)
+}
+
+// streamToTrack writes the given bytes to the buffer of the output, starting a new track if needed
+func (w *writer) streamToTrack(b []byte) {
+	if w.error != nil {
+		return
+	}
+
+	if !w.trackOpen {
+		pos, err := w.seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			w.error = fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+			return
+		}
+
+		// the length is patched at the end of the track
+		_, err = w.buffered.Write([]byte{byte('M'), byte('T'), byte('r'), byte('k'), 0, 0, 0, 0})
+		if err != nil {
+			w.error = fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+			return
+		}
+
+		w.trackLenPos = pos + 4
+		w.trackLen = 0
+		w.trackOpen = true
+	}
+
+	n, err := w.buffered.Write(b)
+	w.trackLen += uint32(n)
+
+	if err != nil {
+		w.error = fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+	}
+}
+
+// endDynamicTrack finishes the current track after the meta.EndOfTrack message has been added
+func (w *writer) endDynamicTrack() (err error) {
+	if w.error != nil {
+		return w.error
+	}
+
+	if w.seeker != nil {
+		err = w.buffered.Flush()
+		if err == nil {
+			err = w.patch(w.trackLenPos, w.trackLen)
+		}
+		w.trackOpen = false
+	} else {
+		_, err = w.track.WriteTo(&w.tracks)
+		w.track.Clear()
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+	}
+
+	if !w.noRunningStatus {
+		w.runningWriter = runningstatus.NewSMFWriter()
+	}
+
+	w.deltatime = 0
+	w.tracksProcessed++
+	w.header.NumTracks = w.tracksProcessed
+
+	return nil
+}
+
+// checkDynamicFormat is called before a message is added and sets the format to SMF1,
+// if there is more than one track and the format has not been given
+func (w *writer) checkDynamicFormat() error {
+	if w.tracksProcessed == 0 || w.header.Format != smf.SMF0 {
+		return nil
+	}
+
+	if w.formatSet {
+		w.error = fmt.Errorf("could not write track %v: SMF0 has a single track", w.tracksProcessed+1)
+		return w.error
+	}
+
+	w.header.Format = smf.SMF1
+	return nil
+}
+
+// finish ends the pending track and completes the file.
+// Any further writing returns smf.ErrFinished.
+func (w *writer) finish() (err error) {
+	if w.error == smf.ErrFinished {
+		return nil
+	}
+
+	if !w.headerWritten {
+		w.WriteHeader()
+	}
+
+	if w.error != nil {
+		return w.error
+	}
+
+	// the pending track has not been finished with meta.EndOfTrack
+	if w.trackOpen || w.track.Len() > 0 || len(w.absMessages) > 0 {
+		err = w.Write(meta.EndOfTrack)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.seeker != nil {
+		// patch format and number of tracks that follow the header chunk type and length
+		err = w.patch(w.headerPos+8, uint32(w.header.Format.Type())<<16|uint32(w.header.NumTracks))
+	} else {
+		err = w.writeHeader(w.output)
+		if err == nil {
+			_, err = w.tracks.WriteTo(w.output)
+		}
+	}
+
+	if err != nil {
+		w.error = fmt.Errorf("could not finish writing: %v", err)
+		return w.error
+	}
+
+	w.error = smf.ErrFinished
+	return nil
+}
+
+// patch overwrites the 4 bytes at pos with val and returns to the current position
+func (w *writer) patch(pos int64, val uint32) error {
+	cur, err := w.seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.seeker.Seek(pos, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w.seeker, binary.BigEndian, val)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.seeker.Seek(cur, io.SeekStart)
+	return err
+}
@@ -0,0 +1,227 @@
+package smfwriter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// seekBuffer is an in memory io.WriteSeeker
+type seekBuffer struct {
+	data   []byte
+	pos    int
+	writes int
+}
+
+func (s *seekBuffer) Write(b []byte) (int, error) {
+	s.writes++
+	if end := s.pos + len(b); end > len(s.data) {
+		s.data = append(s.data, make([]byte, end-len(s.data))...)
+	}
+	n := copy(s.data[s.pos:], b)
+	s.pos += n
+	return n, nil
+}
+
+func (s *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		s.pos = int(offset)
+	case io.SeekCurrent:
+		s.pos += int(offset)
+	case io.SeekEnd:
+		s.pos = len(s.data) + int(offset)
+	}
+	return int64(s.pos), nil
+}
+
+// pipe is an io.WriteSeeker that can't seek
+type pipe struct {
+	bytes.Buffer
+}
+
+func (p *pipe) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.New("illegal seek")
+}
+
+// writeSMF1 writes the tracks of examples.SpecSMF1
+func writeSMF1(wr smf.Writer) {
+	resolution := smf.MetricTicks(96)
+
+	wr.Write(meta.TimeSig{
+		Numerator:                4,
+		Denominator:              4,
+		ClocksPerClick:           24,
+		DemiSemiQuaverPerQuarter: 8,
+	})
+	wr.Write(meta.BPM(120))
+	wr.SetDelta(resolution.Ticks4th() * 4)
+	wr.Write(meta.EndOfTrack)
+
+	wr.Write(channel.Channel0.ProgramChange(5))
+	wr.SetDelta(resolution.Ticks4th() * 2)
+	wr.Write(channel.Channel0.NoteOn(76, 32))
+	wr.SetDelta(resolution.Ticks4th() * 2)
+	wr.Write(channel.Channel0.NoteOff(76))
+	wr.Write(meta.EndOfTrack)
+
+	wr.Write(channel.Channel1.ProgramChange(46))
+	wr.SetDelta(resolution.Ticks4th())
+	wr.Write(channel.Channel1.NoteOn(67, 64))
+	wr.SetDelta(resolution.Ticks4th() * 3)
+	wr.Write(channel.Channel1.NoteOff(67))
+	wr.Write(meta.EndOfTrack)
+
+	wr.Write(channel.Channel2.ProgramChange(70))
+	wr.Write(channel.Channel2.NoteOn(48, 96))
+	wr.Write(channel.Channel2.NoteOn(60, 96))
+	wr.SetDelta(resolution.Ticks4th() * 4)
+	wr.Write(channel.Channel2.NoteOff(48))
+	wr.Write(channel.Channel2.NoteOff(60))
+
+	// the last meta.EndOfTrack is written by Close
+}
+
+func TestDynamicTracks(t *testing.T) {
+	var bf bytes.Buffer
+	var sb seekBuffer
+	var p pipe
+
+	// the header must be patched at the right position
+	prefix := []byte("prefix")
+	sb.Write(prefix)
+
+	tests := []struct {
+		descr  string
+		output io.Writer
+		result func() []byte
+	}{
+		{"memory", &bf, bf.Bytes},
+		{"seek", &sb, func() []byte { return bytes.TrimPrefix(sb.data, prefix) }},
+		{"not seekable", &p, p.Bytes},
+	}
+
+	for _, test := range tests {
+		wr := New(test.output, DynamicTracks(), TimeFormat(smf.MetricTicks(96)))
+		writeSMF1(wr)
+
+		err := wr.(io.Closer).Close()
+
+		if err != nil {
+			t.Fatalf("[%s] Close returned error: %v", test.descr, err)
+		}
+
+		if got, want := test.result(), examples.SpecSMF1; !bytes.Equal(got, want) {
+			t.Errorf("[%s] got:\n% X\n\nwanted:\n% X\n\n", test.descr, got, want)
+		}
+
+		if got, want := wr.Header().NumTracks, uint16(4); got != want {
+			t.Errorf("[%s] Header().NumTracks = %v, wanted %v", test.descr, got, want)
+		}
+
+		if err := wr.Write(meta.EndOfTrack); err != smf.ErrFinished {
+			t.Errorf("[%s] Write after Close returned %v, wanted smf.ErrFinished", test.descr, err)
+		}
+	}
+}
+
+func TestDynamicTracksWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smfwriter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "dynamic.mid")
+
+	err = WriteFile(file, writeSMF1, DynamicTracks(), TimeFormat(smf.MetricTicks(96)))
+	if err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	got, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := examples.SpecSMF1; !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, want)
+	}
+}
+
+func TestDynamicTracksFormat(t *testing.T) {
+	tests := []struct {
+		format   smf.Format
+		expected smf.Format
+		fail     bool
+	}{
+		{nil, smf.SMF1, false},
+		{smf.SMF0, smf.SMF0, true},
+		{smf.SMF1, smf.SMF1, false},
+		{smf.SMF2, smf.SMF2, false},
+	}
+
+	for _, test := range tests {
+		var bf bytes.Buffer
+		opts := []Option{DynamicTracks()}
+
+		if test.format != nil {
+			opts = append(opts, Format(test.format))
+		}
+
+		wr := New(&bf, opts...)
+		wr.Write(channel.Channel0.NoteOn(60, 100))
+		wr.Write(meta.EndOfTrack)
+		err := wr.Write(channel.Channel1.NoteOn(60, 100))
+
+		if got := err != nil; got != test.fail {
+			t.Errorf("[%v] writing the second track returned %v", test.format, err)
+		}
+
+		if got := wr.Header().Format; got != test.expected {
+			t.Errorf("[%v] Header().Format = %v, wanted %v", test.format, got, test.expected)
+		}
+	}
+}
+
+func TestDynamicTracksBuffered(t *testing.T) {
+	var sb seekBuffer
+
+	wr := New(&sb, DynamicTracks())
+	wr.WriteHeader()
+	start := 14
+
+	for tr := 0; tr < 2; tr++ {
+		writes := sb.writes
+
+		for i := 0; i < 100; i++ {
+			wr.SetDelta(10)
+			wr.Write(channel.Channel0.NoteOn(60, 100))
+			wr.SetDelta(10)
+			wr.Write(channel.Channel0.NoteOff(60))
+		}
+
+		wr.Write(meta.EndOfTrack)
+
+		// the buffer is flushed and the length is patched at the end of the track
+		if got := sb.writes - writes; got > 2 {
+			t.Errorf("[track %v] got %v writes, wanted at most 2", tr, got)
+		}
+
+		if got, want := binary.BigEndian.Uint32(sb.data[start+4:]), uint32(len(sb.data)-start-8); got != want {
+			t.Errorf("[track %v] length = %v, wanted %v", tr, got, want)
+		}
+
+		start = len(sb.data)
+	}
+}
@@ -0,0 +1,8 @@
+package smfwriter
+
+import "errors"
+
+var (
+	// ErrRealtimeSysCommon is returned when writing a realtime or system common message with the RejectRealtimeSysCommon option
+	ErrRealtimeSysCommon = errors.New("realtime and system common messages are not allowed inside SMF")
+)
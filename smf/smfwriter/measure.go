@@ -0,0 +1,112 @@
+package smfwriter
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// TimeWriter is a smf.Writer that also allows to set the position of the next message as time.
+// The writer returned by New (and passed to the callback of WriteFile) is a TimeWriter.
+type TimeWriter interface {
+	smf.Writer
+
+	// SetTime sets the time of the next message since the start of the file (independent of the track).
+	// The time is converted to ticks based on the time format and the tempo messages of the first track
+	// that have been written so far.
+	// It takes precedence over the clock that has been set via MeasureTime.
+	SetTime(d time.Duration)
+}
+
+var _ TimeWriter = &writer{}
+
+// SetTime sets the time of the next message since the start of the file.
+func (w *writer) SetTime(d time.Duration) {
+	w.timeSet = true
+	w.nextTime = d
+}
+
+// tempoChange is a tempo message of the first track at the given time and ticks
+type tempoChange struct {
+	time  time.Duration
+	ticks uint32
+	tempo meta.Tempo
+}
+
+// setTimePosition sets the position of the message based on its time
+func (w *writer) setTimePosition(m midi.Message) {
+	d := w.nextTime
+
+	if !w.timeSet {
+		d = w.clock()
+	}
+
+	w.timeSet = false
+	w.SetAbsTicks(w.quantizeTicks(w.timeToTicks(d, len(w.tempos))))
+
+	if t, is := m.(meta.Tempo); is && t > 0 && w.tracksProcessed == 0 {
+		w.addTempo(d, t)
+	}
+}
+
+// timeToTicks converts the time to ticks based on the first n tempo changes (without quantization)
+func (w *writer) timeToTicks(d time.Duration, n int) uint32 {
+	// the last tempo change at or before d
+	i := sort.Search(n, func(i int) bool {
+		return w.tempos[i].time > d
+	}) - 1
+
+	if i < 0 {
+		return w.durationToTicks(d, meta.BPM(120))
+	}
+
+	return w.tempos[i].ticks + w.durationToTicks(d-w.tempos[i].time, w.tempos[i].tempo)
+}
+
+// quantizeTicks rounds the ticks to the nearest multiple of the quantization
+func (w *writer) quantizeTicks(ticks uint32) uint32 {
+	if w.quantize > 1 {
+		ticks = (ticks + w.quantize/2) / w.quantize * w.quantize
+	}
+
+	return ticks
+}
+
+// addTempo adds a tempo change to the tempo map. The ticks of the following tempo changes are recalculated.
+func (w *writer) addTempo(d time.Duration, tempo meta.Tempo) {
+	i := sort.Search(len(w.tempos), func(i int) bool {
+		return w.tempos[i].time >= d
+	})
+
+	if i == len(w.tempos) || w.tempos[i].time != d {
+		w.tempos = append(w.tempos, tempoChange{})
+		copy(w.tempos[i+1:], w.tempos[i:])
+	}
+
+	w.tempos[i] = tempoChange{time: d, tempo: tempo}
+
+	for j := i; j < len(w.tempos); j++ {
+		w.tempos[j].ticks = w.timeToTicks(w.tempos[j].time, j)
+	}
+}
+
+// durationToTicks converts a duration to ticks based on the time format and the given tempo
+func (w *writer) durationToTicks(d time.Duration, tempo meta.Tempo) uint32 {
+	switch tf := w.header.TimeFormat.(type) {
+	case smf.MetricTicks:
+		return tf.FractionalTicks(tempo.FractionalBPM(), d)
+	case smf.TimeCode:
+		fps := float64(tf.FramesPerSecond)
+		// 30 drop frame
+		if tf.FramesPerSecond == 29 {
+			fps = 29.97
+		}
+		return uint32(math.Round(d.Seconds() * fps * float64(tf.SubFrames)))
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,193 @@
+package smfwriter
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+func readDeltas(t *testing.T, data []byte) string {
+	var res bytes.Buffer
+
+	rd := smfreader.New(bytes.NewReader(data))
+
+	for {
+		m, err := rd.Read()
+		if err != nil {
+			break
+		}
+		fmt.Fprintf(&res, "%v@%v %s\n", rd.Track(), rd.Delta(), m)
+	}
+
+	return res.String()
+}
+
+func TestMeasureTime(t *testing.T) {
+	var bf bytes.Buffer
+
+	var now time.Duration
+	clock := func() time.Duration { return now }
+
+	wr := New(&bf, TimeFormat(smf.MetricTicks(96)), MeasureTime(clock))
+
+	wr.Write(channel.Channel0.NoteOn(60, 100))
+
+	// a quarter note at 120 BPM, SetDelta is ignored
+	now = 500 * time.Millisecond
+	wr.SetDelta(1000)
+	wr.Write(channel.Channel0.NoteOff(60))
+	wr.Write(meta.BPM(60))
+
+	// a quarter note at 60 BPM
+	now = 1500 * time.Millisecond
+	wr.Write(channel.Channel0.NoteOn(62, 100))
+
+	// an explicit time takes precedence
+	wr.(TimeWriter).SetTime(1750 * time.Millisecond)
+	wr.Write(channel.Channel0.NoteOff(62))
+
+	wr.Write(meta.EndOfTrack)
+
+	expected := `0@0 channel.NoteOn channel 0 key 60 velocity 100
+0@96 channel.NoteOff channel 0 key 60
+0@0 meta.Tempo BPM: 60.00
+0@96 channel.NoteOn channel 0 key 62 velocity 100
+0@24 channel.NoteOff channel 0 key 62
+0@0 meta.EndOfTrack
+`
+
+	if got := readDeltas(t, bf.Bytes()); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestSetTimeQuantize(t *testing.T) {
+	tests := []struct {
+		timeformat smf.TimeFormat
+		quantize   uint32
+		expected   string
+	}{
+		{
+			smf.MetricTicks(96),
+			0,
+			`0@0 channel.NoteOn channel 0 key 60 velocity 100
+0@54 channel.NoteOff channel 0 key 60
+0@0 meta.EndOfTrack
+1@2 channel.NoteOn channel 1 key 60 velocity 100
+1@101 channel.NoteOff channel 1 key 60
+1@0 meta.EndOfTrack
+`,
+		},
+		{
+			smf.MetricTicks(96),
+			24,
+			`0@0 channel.NoteOn channel 0 key 60 velocity 100
+0@48 channel.NoteOff channel 0 key 60
+0@0 meta.EndOfTrack
+1@0 channel.NoteOn channel 1 key 60 velocity 100
+1@96 channel.NoteOff channel 1 key 60
+1@0 meta.EndOfTrack
+`,
+		},
+		{
+			smf.SMPTE25(40),
+			0,
+			`0@0 channel.NoteOn channel 0 key 60 velocity 100
+0@280 channel.NoteOff channel 0 key 60
+0@0 meta.EndOfTrack
+1@10 channel.NoteOn channel 1 key 60 velocity 100
+1@525 channel.NoteOff channel 1 key 60
+1@0 meta.EndOfTrack
+`,
+		},
+	}
+
+	for _, test := range tests {
+		var bf bytes.Buffer
+
+		wr := New(&bf, TimeFormat(test.timeformat), NumTracks(2), Quantize(test.quantize)).(TimeWriter)
+
+		wr.SetTime(0)
+		wr.Write(channel.Channel0.NoteOn(60, 100))
+		wr.SetTime(280 * time.Millisecond)
+		wr.Write(channel.Channel0.NoteOff(60))
+		wr.Write(meta.EndOfTrack)
+
+		// the time is counted from the start of the file
+		wr.SetTime(10 * time.Millisecond)
+		wr.Write(channel.Channel1.NoteOn(60, 100))
+		wr.SetTime(535 * time.Millisecond)
+		wr.Write(channel.Channel1.NoteOff(60))
+		wr.Write(meta.EndOfTrack)
+
+		if got := readDeltas(t, bf.Bytes()); got != test.expected {
+			t.Errorf("[%s quantize %v] got:\n%s\nwanted:\n%s", test.timeformat, test.quantize, got, test.expected)
+		}
+	}
+}
+
+func TestSetTimeTempoMap(t *testing.T) {
+	var bf bytes.Buffer
+
+	wr := New(&bf, TimeFormat(smf.MetricTicks(96)), NumTracks(2)).(TimeWriter)
+
+	// the conductor track
+	wr.SetTime(0)
+	wr.Write(meta.BPM(120))
+	wr.SetTime(2 * time.Second)
+	wr.Write(meta.BPM(60))
+	wr.Write(meta.EndOfTrack)
+
+	// events before and after the last tempo change
+	wr.SetTime(500 * time.Millisecond)
+	wr.Write(channel.Channel1.NoteOn(60, 100))
+	wr.SetTime(time.Second)
+	wr.Write(channel.Channel1.NoteOff(60))
+	wr.SetTime(3 * time.Second)
+	wr.Write(channel.Channel1.NoteOn(62, 100))
+	wr.SetTime(3500 * time.Millisecond)
+	wr.Write(channel.Channel1.NoteOff(62))
+	wr.Write(meta.EndOfTrack)
+
+	expected := `0@0 meta.Tempo BPM: 120.00
+0@384 meta.Tempo BPM: 60.00
+0@0 meta.EndOfTrack
+1@96 channel.NoteOn channel 1 key 60 velocity 100
+1@96 channel.NoteOff channel 1 key 60
+1@288 channel.NoteOn channel 1 key 62 velocity 100
+1@48 channel.NoteOff channel 1 key 62
+1@0 meta.EndOfTrack
+`
+
+	if got := readDeltas(t, bf.Bytes()); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
+
+func TestSetTimeTempoQuantize(t *testing.T) {
+	var bf bytes.Buffer
+
+	wr := New(&bf, TimeFormat(smf.MetricTicks(960)), NumTracks(1), Quantize(960)).(TimeWriter)
+
+	// the tempo change is quantized to 0, but the following times are based on its exact position (384 ticks)
+	wr.SetTime(200 * time.Millisecond)
+	wr.Write(meta.BPM(60))
+	wr.SetTime(600 * time.Millisecond)
+	wr.Write(channel.Channel0.NoteOn(60, 100))
+	wr.Write(meta.EndOfTrack)
+
+	expected := `0@0 meta.Tempo BPM: 60.00
+0@960 channel.NoteOn channel 0 key 60 velocity 100
+0@0 meta.EndOfTrack
+`
+
+	if got := readDeltas(t, bf.Bytes()); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+}
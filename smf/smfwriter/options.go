@@ -1,6 +1,8 @@
 package smfwriter
 
 import (
+	"time"
+
 	"github.com/gomidi/midi/smf"
 )
 
@@ -8,17 +10,20 @@ import (
 TODO
 defaults:
 	- store sysex if they are written
-	- ignore realtime and System Common or System Real-Time messages if they are written
 	- take deltas set via SetDelta
 	- no quantization/rounding
 options:
   - ignore incoming sysex
-  - store incoming realtime and System Common or System Real-Time messages (escape them inside a sysex)
-  - ignore SetDelta and measure time instead (autogenerating delta on the way)
-  - allow delta quantization/rounding
 
 */
 
+// policies for realtime and system common messages
+const (
+	rtscIgnore uint8 = iota
+	rtscReject
+	rtscEscape
+)
+
 // Option is a Writer option
 type Option func(*writer)
 
@@ -46,6 +51,7 @@ func TimeFormat(timeformat smf.TimeFormat) Option {
 // the number of tracks must be given to Writer, before the MIDI events could be written.
 // If the number of tracks is not given - or 0 - , it defaults to 1 track.
 // If the given number of tracks has been written, any further writing returns an io.EOF error.
+// If the number of tracks is not known in advance, use DynamicTracks instead.
 func NumTracks(ntracks uint16) Option {
 	if ntracks == 0 {
 		ntracks = 1
@@ -55,11 +61,90 @@ func NumTracks(ntracks uint16) Option {
 	}
 }
 
+// DynamicTracks lets the writer determine the number of tracks by the tracks that are actually written.
+// Then NumTracks is ignored and the writing of tracks is finished by calling Close on the writer
+// (the writer returned by New implements io.Closer). WriteFile does this automatically.
+//
+// If the io.Writer is an io.WriteSeeker (e.g. an *os.File), the tracks are written directly and the
+// number of tracks in the header and the length of each track are patched afterwards.
+// Otherwise the tracks are kept in memory and the complete file is written when Close is called.
+//
+// If Format is not given, the format is SMF0 if a single track has been written, otherwise SMF1.
+// If Format is smf.SMF0, writing a second track returns an error.
+func DynamicTracks() Option {
+	return func(w *writer) {
+		w.dynamic = true
+	}
+}
+
+// AbsoluteTicks lets the writer take the messages of a track in any order.
+// The position of a message is set via SetAbsTicks (see AbsWriter) or moved via SetDelta and stays
+// the same for the following messages until it is changed again.
+// The messages are kept until the meta.EndOfTrack message is written. Then they are sorted by
+// their position and written with the corresponding deltas.
+//
+// Messages at the same position are written in the following order: meta messages, note off messages
+// (including note on messages with velocity 0), other messages and finally note on messages.
+// Otherwise the order in which they have been written is kept.
+// The meta.EndOfTrack message is placed at the current position or after the last message, whichever comes later.
+func AbsoluteTicks() Option {
+	return func(w *writer) {
+		w.absolute = true
+	}
+}
+
+// MeasureTime lets the writer ignore SetDelta and measure the time instead.
+// clock returns the time since the start of the file. If clock is nil, the time since the creation of
+// the writer is taken.
+// When a message is written, its time is converted to ticks based on the time format and the tempo messages
+// of the first track (the conductor track) that have been written so far (120 BPM before the first one).
+// The ticks are counted from the start of the file for every track, so that tracks may be written one after another.
+// See also TimeWriter and Quantize.
+func MeasureTime(clock func() time.Duration) Option {
+	return func(w *writer) {
+		if clock == nil {
+			start := time.Now()
+			clock = func() time.Duration {
+				return time.Since(start)
+			}
+		}
+		w.clock = clock
+	}
+}
+
+// Quantize rounds the positions of messages that are written with MeasureTime or TimeWriter.SetTime
+// to the nearest multiple of grid ticks (e.g. smf.MetricTicks(960).Ticks16th()).
+func Quantize(grid uint32) Option {
+	return func(w *writer) {
+		w.quantize = grid
+	}
+}
+
+// RejectRealtimeSysCommon lets Write return ErrRealtimeSysCommon for realtime and system common messages,
+// since they are not allowed inside SMF files. The writing is not blocked by this error.
+// Without this option (or EscapeRealtimeSysCommon), these messages are ignored (default).
+func RejectRealtimeSysCommon() Option {
+	return func(w *writer) {
+		w.realtimeSysCommon = rtscReject
+	}
+}
+
+// EscapeRealtimeSysCommon lets the writer store realtime and system common messages as sysex.Escape
+// messages (F7 <length> <message>), since they are not allowed inside SMF files.
+// Use smfreader.UnescapeRealtimeSysCommon to get them back when reading.
+// Without this option (or RejectRealtimeSysCommon), these messages are ignored (default).
+func EscapeRealtimeSysCommon() Option {
+	return func(w *writer) {
+		w.realtimeSysCommon = rtscEscape
+	}
+}
+
 // Format sets the SMF file format version.
 // Valid values are: smf.SMF0 (single track), smf.SMF1 (multi track), smf.SMF2 (sequential track)
 // If this option is not given, SMF0 will be used as default if the number of tracks is 1, otherwise SMF1.
 func Format(f smf.Format) Option {
 	return func(w *writer) {
 		w.header.Format = f
+		w.formatSet = true
 	}
 }
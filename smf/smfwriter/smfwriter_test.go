@@ -10,6 +10,8 @@ import (
 	"github.com/gomidi/midi/internal/examples"
 	"github.com/gomidi/midi/midimessage/channel"
 	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
 	"github.com/gomidi/midi/midimessage/sysex"
 	"github.com/gomidi/midi/smf"
 	"github.com/gomidi/midi/smf/smfreader"
@@ -256,3 +258,52 @@ func TestNoRunningStatus(t *testing.T) {
 		t.Errorf("got:\n%#v\nwanted:\n%#v\n\n", got, want)
 	}
 }
+
+func TestRealtimeSysCommon(t *testing.T) {
+	tests := []struct {
+		options  []Option
+		expected string
+	}{
+		{
+			nil,
+			`0@5 channel.NoteOn channel 0 key 60 velocity 100
+0@0 meta.EndOfTrack
+`,
+		},
+		{
+			[]Option{EscapeRealtimeSysCommon()},
+			`0@2 sysex.Escape len: 1
+0@0 sysex.Escape len: 3
+0@3 channel.NoteOn channel 0 key 60 velocity 100
+0@0 meta.EndOfTrack
+`,
+		},
+		{
+			[]Option{RejectRealtimeSysCommon()},
+			`0@5 channel.NoteOn channel 0 key 60 velocity 100
+0@0 meta.EndOfTrack
+`,
+		},
+	}
+
+	for i, test := range tests {
+		var bf bytes.Buffer
+
+		wr := New(&bf, test.options...)
+		wr.SetDelta(2)
+		wr.Write(realtime.TimingClock)
+		err := wr.Write(syscommon.SPP(4))
+
+		if rejected := err == ErrRealtimeSysCommon; rejected != (i == 2) {
+			t.Errorf("[%v] Write returned error %v", i, err)
+		}
+
+		wr.SetDelta(3)
+		wr.Write(channel.Channel0.NoteOn(60, 100))
+		wr.Write(meta.EndOfTrack)
+
+		if got := readDeltas(t, bf.Bytes()); got != test.expected {
+			t.Errorf("[%v] got:\n%s\nwanted:\n%s", i, got, test.expected)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package smfwriter
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/gomidi/midi/internal/runningstatus"
 	"github.com/gomidi/midi/internal/vlq"
@@ -14,6 +16,9 @@ import (
 	"github.com/gomidi/midi"
 
 	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
+	"github.com/gomidi/midi/midimessage/sysex"
 	"github.com/gomidi/midi/smf"
 )
 
@@ -59,7 +64,11 @@ func WriteFile(file string, callback func(smf.Writer), options ...Option) error
 	}
 
 	// make sure the data of the last track is written
-	err = wr.Write(meta.EndOfTrack)
+	if wr.dynamic {
+		err = wr.finish()
+	} else {
+		err = wr.Write(meta.EndOfTrack)
+	}
 
 	if err != nil && err != smf.ErrFinished {
 		f.Close()
@@ -91,6 +100,7 @@ func New(dest io.Writer, opts ...Option) smf.Writer {
 
 type writer struct {
 	header          smf.Header
+	formatSet       bool
 	track           smf.Chunk
 	output          io.Writer
 	headerWritten   bool
@@ -99,9 +109,43 @@ type writer struct {
 	noRunningStatus bool
 	error           error
 	runningWriter   runningstatus.SMFWriter
+
+	// for DynamicTracks, see dynamic.go
+	dynamic     bool
+	seeker      io.WriteSeeker
+	buffered    *bufio.Writer
+	headerPos   int64
+	trackLenPos int64
+	trackLen    uint32
+	trackOpen   bool
+	tracks      bytes.Buffer
+
+	// for AbsoluteTicks, see absolute.go
+	absolute    bool
+	absPos      uint32
+	absMessages []absMessage
+
+	realtimeSysCommon uint8
+	skippedDelta      uint32
+
+	// for MeasureTime, Quantize and SetTime, see measure.go
+	clock    func() time.Duration
+	quantize uint32
+	timeSet  bool
+	nextTime time.Duration
+	tempos   []tempoChange
 }
 
+// Close completes the file, if the writer was created with the DynamicTracks option.
+// Then it closes the underlying io.Writer if it is an io.WriteCloser.
 func (w *writer) Close() error {
+	if w.dynamic {
+		err := w.finish()
+		if err != nil {
+			return err
+		}
+	}
+
 	if cl, is := w.output.(io.WriteCloser); is {
 		return cl.Close()
 	}
@@ -112,6 +156,13 @@ func (w *writer) WriteHeader() error {
 	if w.headerWritten {
 		return w.error
 	}
+
+	if w.dynamic {
+		w.headerWritten = true
+		w.error = w.startDynamic()
+		return w.error
+	}
+
 	err := w.writeHeader(w.output)
 	w.headerWritten = true
 
@@ -154,11 +205,28 @@ func newWriter(output io.Writer, opts ...Option) *writer {
 	return wr
 }
 
-// SetDelta sets the delta time in ticks for the next message(s)
+// SetDelta sets the delta time in ticks for the next message(s).
+// With the AbsoluteTicks option the position of the next message(s) is moved by deltatime.
 func (w *writer) SetDelta(deltatime uint32) {
+	// the time is measured instead
+	if w.clock != nil {
+		return
+	}
+
+	if w.absolute {
+		w.absPos += deltatime
+		return
+	}
 	w.deltatime = deltatime
 }
 
+// skipMessage keeps the delta of a message that is not written for the next message
+func (w *writer) skipMessage() {
+	w.skippedDelta += w.deltatime
+	w.deltatime = 0
+	w.timeSet = false
+}
+
 // Header returns the smf.Header of the file
 func (w *writer) Header() smf.Header {
 	return w.header
@@ -178,25 +246,65 @@ func (w *writer) Write(m midi.Message) (err error) {
 		w.error = fmt.Errorf("writing header before midi message %#v failed: %v", m, w.error)
 		return w.error
 	}
+	if !w.dynamic && w.header.NumTracks == w.tracksProcessed {
+		w.error = smf.ErrFinished
+		return w.error
+	}
+	if w.dynamic {
+		if err = w.checkDynamicFormat(); err != nil {
+			return
+		}
+	}
+
+	// realtime and system common messages are not allowed inside SMF files
+	switch m.(type) {
+	case realtime.Message, syscommon.Message:
+		switch w.realtimeSysCommon {
+		case rtscEscape:
+			m = sysex.Escape(m.Raw())
+		case rtscReject:
+			w.skipMessage()
+			return ErrRealtimeSysCommon
+		default:
+			w.skipMessage()
+			return nil
+		}
+	}
+
 	defer func() {
 		w.deltatime = 0
 	}()
 
-	if w.header.NumTracks == w.tracksProcessed {
-		w.error = smf.ErrFinished
-		return w.error
+	w.deltatime += w.skippedDelta
+	w.skippedDelta = 0
+
+	if w.clock != nil || w.timeSet {
+		w.setTimePosition(m)
 	}
 
 	if m == meta.EndOfTrack {
+		if w.absolute {
+			w.deltatime = w.flushAbsMessages()
+		}
 		w.addMessage(w.deltatime, m)
-		err = w.writeTrackTo(w.output)
+		w.absPos = 0
+		if w.dynamic {
+			err = w.endDynamicTrack()
+		} else {
+			err = w.writeTrackTo(w.output)
+		}
 		if err != nil {
 			w.error = err
 		}
 		return
 	}
+	if w.absolute {
+		w.addAbsMessage(m)
+		return nil
+	}
+	w.absPos += w.deltatime
 	w.addMessage(w.deltatime, m)
-	return
+	return w.error
 }
 
 /*
@@ -296,6 +404,10 @@ func (w *writer) writeTrackTo(wr io.Writer) (err error) {
 }
 
 func (w *writer) appendToChunk(deltaTime uint32, b []byte) {
+	if w.seeker != nil {
+		w.streamToTrack(append(vlq.Encode(deltaTime), b...))
+		return
+	}
 	w.track.Write(append(vlq.Encode(deltaTime), b...))
 	//t.track.data = append(t.track.data, append(vlq.Encode(deltaTime), b...)...)
 }